@@ -0,0 +1,127 @@
+package webgen
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// writeTemplateConstructor writes the template variable and the constructor
+// that clones it, for Options.CloneTemplates.
+func writeTemplateConstructor(w io.Writer, d dialect, c *component, injectCSS bool) {
+	tplName := "_" + c.typeName + "Template"
+	fmt.Fprintf(w, "var %s = &_template{html: %s}\n\n", tplName, goStringLiteral(templateHTML(c.roots)))
+
+	fmt.Fprintf(w, "func %s() *%s {\n", constructorFuncName(c.typeName), c.typeName)
+	fmt.Fprintf(w, "roots := %s.clone()\n", tplName)
+
+	// Locate all needed elements before replacing placeholders, which
+	// changes child indices.
+	e := templateEmitter{
+		w:     w,
+		namer: newVarNames(),
+		vars:  make(map[*node]string),
+	}
+	for i, n := range c.roots {
+		e.node(n, []int{i})
+	}
+
+	for i, n := range e.includes {
+		varName := e.vars[n]
+		fmt.Fprintf(w, "%s := %s\n", varName, includeConstructorCall(n))
+		fmt.Fprintf(w, "_replace(_p%d, %s)\n", i, includeRoots(n, varName))
+	}
+
+	if injectCSS {
+		fmt.Fprintf(w, "%s()\n", injectCSSFuncName(c.typeName))
+	}
+	writeReturn(w, d, c.typeName, c.refs, e.vars, "roots")
+	fmt.Fprint(w, "\n}")
+}
+
+// templateEmitter writes the statements of a template constructor that
+// locate elements in the cloned template. The nodes are visited in the same
+// order as by domEmitter, so that variable names match.
+type templateEmitter struct {
+	w        io.Writer
+	namer    varNames
+	vars     map[*node]string // element nodes with refs and include nodes -> var names
+	includes []*node          // include nodes, in document order; placeholder i is _p<i>
+}
+
+// node writes statements that locate n, if needed, and its descendants.
+// path is the root index followed by child element indices.
+func (e *templateEmitter) node(n *node, path []int) {
+	switch n.kind {
+	case elementNode:
+		varName := e.namer.next(n.tag)
+		if n.ref != "" {
+			e.vars[n] = varName
+			fmt.Fprintf(e.w, "%s := %s\n", varName, atCall(path))
+		}
+		var i int
+		for _, child := range n.children {
+			if child.kind == textNode {
+				e.node(child, nil)
+				continue
+			}
+			e.node(child, append(path[:len(path):len(path)], i))
+			i++
+		}
+
+	case textNode:
+		e.namer.next("stringliteral")
+
+	case includeNode:
+		e.vars[n] = e.namer.next(n.tag)
+		fmt.Fprintf(e.w, "_p%d := %s\n", len(e.includes), atCall(path))
+		e.includes = append(e.includes, n)
+	}
+}
+
+func atCall(path []int) string {
+	args := []string{"roots"}
+	for _, i := range path {
+		args = append(args, strconv.Itoa(i))
+	}
+	return "_at(" + strings.Join(args, ", ") + ")"
+}
+
+// templateHTML returns the static markup of the nodes. Include nodes are
+// represented by empty <template> placeholder elements, which are allowed
+// in any context.
+func templateHTML(nodes []*node) string {
+	var b strings.Builder
+	writeTemplateHTML(&b, nodes, "")
+	return b.String()
+}
+
+func writeTemplateHTML(b *strings.Builder, nodes []*node, parentTag string) {
+	for _, n := range nodes {
+		switch n.kind {
+		case elementNode:
+			b.WriteString("<" + n.tag)
+			for _, a := range n.attrs {
+				b.WriteString(" " + a.key + `="` + html.EscapeString(a.val) + `"`)
+			}
+			b.WriteString(">")
+			if isVoidElement(n.tag) {
+				continue
+			}
+			writeTemplateHTML(b, n.children, n.tag)
+			b.WriteString("</" + n.tag + ">")
+
+		case textNode:
+			if isRawTextElement(parentTag) {
+				b.WriteString(n.text)
+			} else {
+				b.WriteString(html.EscapeString(n.text))
+			}
+
+		case includeNode:
+			b.WriteString("<template></template>")
+		}
+	}
+}
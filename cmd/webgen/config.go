@@ -0,0 +1,144 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+)
+
+// defaultConfig is the config file used when webgen is run without input
+// files or directories.
+const defaultConfig = "webgen.json"
+
+// config is the contents of a config file.
+type config struct {
+	Targets []*target `json:"targets"`
+}
+
+// readConfig reads the targets in the config file. Relative paths in the
+// file are relative to the file's directory, and are returned joined to
+// it. If names is non-empty, only the targets with the names are returned.
+func readConfig(path string, names []string) ([]*target, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var c config
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.DisallowUnknownFields()
+	if err := d.Decode(&c); err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err)
+	}
+	if len(c.Targets) == 0 {
+		return nil, fmt.Errorf("%s: no targets", path)
+	}
+
+	dir := filepath.Dir(path)
+	seen := make(map[string]bool)
+	for i, t := range c.Targets {
+		if t.Name == "" {
+			t.Name = fmt.Sprint(i)
+		}
+		if seen[t.Name] {
+			return nil, fmt.Errorf("%s: duplicate target name %q", path, t.Name)
+		}
+		seen[t.Name] = true
+		if err := t.resolveConfig(dir); err != nil {
+			return nil, fmt.Errorf("%s: target %s: %s", path, t.Name, err)
+		}
+	}
+
+	if len(names) == 0 {
+		return c.Targets, nil
+	}
+	var targets []*target
+	requested := make(map[string]bool)
+	for _, name := range names {
+		if requested[name] {
+			return nil, fmt.Errorf("--target lists %q more than once", name)
+		}
+		requested[name] = true
+		if !seen[name] {
+			return nil, fmt.Errorf("%s: no target named %q", path, name)
+		}
+		for _, t := range c.Targets {
+			if t.Name == name {
+				targets = append(targets, t)
+			}
+		}
+	}
+	return targets, nil
+}
+
+// resolveConfig sets the defaults of a target from a config file, and
+// makes its relative paths relative to dir.
+func (t *target) resolveConfig(dir string) error {
+	if len(t.Inputs) == 0 {
+		return errors.New("no inputs")
+	}
+	if t.OutViews == "" && t.OutDir == "" {
+		return errors.New("one of outviews or outdir is required")
+	}
+	if t.OutCSS == "" && !t.EmbedCSS {
+		return errors.New("one of outcss or embed-css is required")
+	}
+
+	if t.Package == "" {
+		t.Package = "views"
+	}
+	if t.Root == "" {
+		t.Root = "."
+	}
+	if t.Backend == "" {
+		t.Backend = "webapi"
+	}
+	if t.Naming == "" {
+		t.Naming = "base"
+	}
+
+	join := func(p *string) {
+		if *p != "" && !filepath.IsAbs(*p) {
+			*p = filepath.Join(dir, *p)
+		}
+	}
+	joinAll := func(ps []string) {
+		for i := range ps {
+			join(&ps[i])
+		}
+	}
+	joinAll(t.Inputs)
+	joinAll(t.CSSOrder)
+	joinAll(t.Manifests)
+	for _, p := range []*string{&t.Root, &t.OutViews, &t.OutDir, &t.OutCSS, &t.OutCSSMap, &t.OutManifest, &t.Depfile} {
+		join(p)
+	}
+	return nil
+}
+
+// configFlags are the flags that can be used with a config file. Other
+// flags are set in the config file instead.
+var configFlags = map[string]bool{
+	"h":              true,
+	"help":           true,
+	"config":         true,
+	"target":         true,
+	"check":          true,
+	"watch":          true,
+	"watch-interval": true,
+	"addr":           true,
+	"dir":            true,
+	"build":          true,
+	"wasm":           true,
+}
+
+// splitNames splits a comma-separated list of names.
+func splitNames(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, ",")
+}
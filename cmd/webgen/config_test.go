@@ -0,0 +1,153 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadConfig(t *testing.T) {
+	dir := t.TempDir()
+	abs := filepath.Join(dir, "abs")
+	in := func(elem ...string) string {
+		return filepath.Join(append([]string{dir, "web"}, elem...)...)
+	}
+
+	testcases := []struct {
+		name   string
+		config string
+		names  []string
+		expect []*target
+		err    string
+	}{
+		{
+			name: "relative paths",
+			config: `{"targets": [{
+				"inputs": ["components", "../shared/Card.html"],
+				"include": ["/widgets/**"],
+				"exclude": ["drafts/"],
+				"root": "components",
+				"outviews": "views/views.go",
+				"outcss": "public/components.css",
+				"outcss-map": "public/components.css.map",
+				"css-order": ["components/Base.html"],
+				"import-path": "example.com/views",
+				"outmanifest": "views/manifest.json",
+				"manifests": ["design/manifest.json"],
+				"depfile": "build/views.d"
+			}]}`,
+			expect: []*target{{
+				Name:        "0",
+				Inputs:      []string{in("components"), filepath.Join(dir, "shared", "Card.html")},
+				Include:     []string{"/widgets/**"}, // relative to the inputs
+				Exclude:     []string{"drafts/"},
+				Package:     "views",
+				Root:        in("components"),
+				OutViews:    in("views", "views.go"),
+				OutCSS:      in("public", "components.css"),
+				OutCSSMap:   in("public", "components.css.map"),
+				CSSOrder:    []string{in("components", "Base.html")},
+				Backend:     "webapi",
+				ImportPath:  "example.com/views",
+				OutManifest: in("views", "manifest.json"),
+				Manifests:   []string{in("design", "manifest.json")},
+				Naming:      "base",
+				Depfile:     in("build", "views.d"),
+			}},
+		},
+		{
+			name: "absolute paths",
+			config: `{"targets": [{
+				"inputs": ["` + filepath.ToSlash(abs) + `"],
+				"outdir": "` + filepath.ToSlash(abs) + `",
+				"embed-css": true
+			}]}`,
+			expect: []*target{{
+				Name:     "0",
+				Inputs:   []string{abs},
+				Package:  "views",
+				Root:     in(),
+				OutDir:   abs,
+				EmbedCSS: true,
+				Backend:  "webapi",
+				Naming:   "base",
+			}},
+		},
+		{
+			name: "names",
+			config: `{"targets": [
+				{"name": "app", "inputs": ["app"], "outviews": "app.go", "embed-css": true},
+				{"name": "admin", "inputs": ["admin"], "outviews": "admin.go", "embed-css": true},
+				{"inputs": ["other"], "outviews": "other.go", "embed-css": true}
+			]}`,
+			names: []string{"2", "app"},
+			expect: []*target{
+				{Name: "2", Inputs: []string{in("other")}, Package: "views", Root: in(), OutViews: in("other.go"), EmbedCSS: true, Backend: "webapi", Naming: "base"},
+				{Name: "app", Inputs: []string{in("app")}, Package: "views", Root: in(), OutViews: in("app.go"), EmbedCSS: true, Backend: "webapi", Naming: "base"},
+			},
+		},
+		{
+			name:   "unknown name",
+			config: `{"targets": [{"name": "app", "inputs": ["app"], "outviews": "app.go", "embed-css": true}]}`,
+			names:  []string{"admin"},
+			err:    `no target named "admin"`,
+		},
+		{
+			name:   "duplicate requested name",
+			config: `{"targets": [{"name": "app", "inputs": ["app"], "outviews": "app.go", "embed-css": true}]}`,
+			names:  []string{"app", "app"},
+			err:    `--target lists "app" more than once`,
+		},
+		{
+			name: "duplicate name",
+			config: `{"targets": [
+				{"name": "app", "inputs": ["app"], "outviews": "app.go", "embed-css": true},
+				{"name": "app", "inputs": ["admin"], "outviews": "admin.go", "embed-css": true}
+			]}`,
+			err: `duplicate target name "app"`,
+		},
+		{
+			name:   "no targets",
+			config: `{"targets": []}`,
+			err:    "no targets",
+		},
+		{
+			name:   "unknown key",
+			config: `{"targets": [{"inputs": ["app"], "outviews": "app.go", "out-css": "app.css"}]}`,
+			err:    `json: unknown field "out-css"`,
+		},
+		{
+			name:   "missing output",
+			config: `{"targets": [{"name": "app", "inputs": ["app"], "embed-css": true}]}`,
+			err:    "target app: one of outviews or outdir is required",
+		},
+	}
+
+	for _, tt := range testcases {
+		t.Run(tt.name, func(t *testing.T) {
+			p := filepath.Join(dir, "web", "webgen.json")
+			writeFile(t, p, tt.config)
+			targets, err := readConfig(p, tt.names)
+			if tt.err != "" {
+				if err == nil || !strings.HasSuffix(err.Error(), tt.err) {
+					t.Errorf("expected error: %s, got: %v", tt.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if !reflect.DeepEqual(targets, tt.expect) {
+				t.Errorf("expected:")
+				for _, x := range tt.expect {
+					t.Errorf("\t%+v", *x)
+				}
+				t.Errorf("got:")
+				for _, x := range targets {
+					t.Errorf("\t%+v", *x)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/kylelemons/godebug/diff"
+)
+
+// diffContext is the number of unchanged lines around changes in a
+// unified diff.
+const diffContext = 3
+
+// diffLine is a line in a diff: op is ' ' for an unchanged line, '-' for a
+// deleted line, or '+' for an added line.
+type diffLine struct {
+	op   byte
+	text string
+}
+
+// unifiedDiff returns a unified diff of the changes from a to b, or the
+// empty string if there are none.
+func unifiedDiff(aName, bName string, a, b []byte) string {
+	var lines []diffLine
+	for _, c := range diff.DiffChunks(splitLines(a), splitLines(b)) {
+		for _, l := range c.Deleted {
+			lines = append(lines, diffLine{'-', l})
+		}
+		for _, l := range c.Added {
+			lines = append(lines, diffLine{'+', l})
+		}
+		for _, l := range c.Equal {
+			lines = append(lines, diffLine{' ', l})
+		}
+	}
+
+	var buf bytes.Buffer
+	aLine, bLine := 1, 1 // line numbers at lines[i]
+	for i := 0; i < len(lines); {
+		if lines[i].op == ' ' {
+			aLine++
+			bLine++
+			i++
+			continue
+		}
+
+		// Start a hunk with up to diffContext lines of context, and extend
+		// it until there are more than 2*diffContext unchanged lines.
+		start := i - diffContext
+		if start < 0 {
+			start = 0
+		}
+		end := i
+		for end < len(lines) {
+			if lines[end].op != ' ' {
+				end++
+				continue
+			}
+			n := 0
+			for end+n < len(lines) && lines[end+n].op == ' ' {
+				n++
+			}
+			if end+n == len(lines) || n > 2*diffContext {
+				if n > diffContext {
+					n = diffContext
+				}
+				end += n
+				break
+			}
+			end += n
+		}
+
+		if buf.Len() == 0 {
+			fmt.Fprintf(&buf, "--- %s\n+++ %s\n", aName, bName)
+		}
+		aStart, bStart := aLine-(i-start), bLine-(i-start)
+		var aCount, bCount int
+		for _, l := range lines[start:end] {
+			if l.op != '+' {
+				aCount++
+			}
+			if l.op != '-' {
+				bCount++
+			}
+		}
+		fmt.Fprintf(&buf, "@@ -%s +%s @@\n", hunkRange(aStart, aCount), hunkRange(bStart, bCount))
+		for _, l := range lines[start:end] {
+			fmt.Fprintf(&buf, "%c%s\n", l.op, l.text)
+		}
+
+		for _, l := range lines[i:end] {
+			if l.op != '+' {
+				aLine++
+			}
+			if l.op != '-' {
+				bLine++
+			}
+		}
+		i = end
+	}
+	return buf.String()
+}
+
+// hunkRange formats the range of lines in a hunk header. An empty range
+// refers to the line before it, by convention.
+func hunkRange(start, count int) string {
+	if count == 0 {
+		start--
+	}
+	if count == 1 {
+		return fmt.Sprint(start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
+
+// noNewline marks the last line of a file that does not end with a newline.
+const noNewline = "\n\\ No newline at end of file"
+
+// splitLines splits b into lines, without line endings. If b does not end
+// with a newline, noNewline is appended to the last line, so that the line
+// differs from the same line with a newline, and the diff shows the
+// marker after it.
+func splitLines(b []byte) []string {
+	if len(b) == 0 {
+		return nil
+	}
+	lines := strings.Split(strings.TrimSuffix(string(b), "\n"), "\n")
+	if b[len(b)-1] != '\n' {
+		lines[len(lines)-1] += noNewline
+	}
+	return lines
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// numberedLines returns the lines "1" to "n", with the lines at the keys of
+// replace replaced.
+func numberedLines(n int, replace map[int]string) string {
+	var b strings.Builder
+	for i := 1; i <= n; i++ {
+		if s, ok := replace[i]; ok {
+			b.WriteString(s)
+		} else {
+			fmt.Fprint(&b, i)
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	testcases := []struct {
+		name, a, b, expect string
+	}{
+		{"equal", "a\nb\n", "a\nb\n", ""},
+		{"empty", "", "", ""},
+		{"one line", "a\n", "b\n", "@@ -1 +1 @@\n-a\n+b\n"},
+		{"added file", "", "a\nb\n", "@@ -0,0 +1,2 @@\n+a\n+b\n"},
+		{"removed file", "a\nb\n", "", "@@ -1,2 +0,0 @@\n-a\n-b\n"},
+		{"added newline", "a\nb", "a\nb\n", "@@ -1,2 +1,2 @@\n a\n-b\n\\ No newline at end of file\n+b\n"},
+		{"removed newline", "a\nb\n", "a\nb", "@@ -1,2 +1,2 @@\n a\n-b\n+b\n\\ No newline at end of file\n"},
+		{"no newline in either", "a\nb", "a\nc", "@@ -1,2 +1,2 @@\n a\n-b\n\\ No newline at end of file\n+c\n\\ No newline at end of file\n"},
+		{"no newline in context", "a\nb", "x\nb", "@@ -1,2 +1,2 @@\n-a\n+x\n b\n\\ No newline at end of file\n"},
+		{"added file without newline", "", "a", "@@ -0,0 +1 @@\n+a\n\\ No newline at end of file\n"},
+		{
+			"context",
+			numberedLines(10, nil),
+			numberedLines(10, map[int]string{5: "x"}),
+			"@@ -2,7 +2,7 @@\n 2\n 3\n 4\n-5\n+x\n 6\n 7\n 8\n",
+		},
+		{
+			"context at start",
+			numberedLines(10, nil),
+			numberedLines(10, map[int]string{1: "x"}),
+			"@@ -1,4 +1,4 @@\n-1\n+x\n 2\n 3\n 4\n",
+		},
+		{
+			"context at end",
+			numberedLines(10, nil),
+			numberedLines(10, map[int]string{10: "x"}),
+			"@@ -7,4 +7,4 @@\n 7\n 8\n 9\n-10\n+x\n",
+		},
+		{
+			"insertion",
+			"1\n2\n3\n4\n5\n6\n7\n8\n",
+			"1\n2\n3\n4\nx\n5\n6\n7\n8\n",
+			"@@ -2,6 +2,7 @@\n 2\n 3\n 4\n+x\n 5\n 6\n 7\n",
+		},
+		{
+			"merged hunks",
+			numberedLines(20, nil),
+			numberedLines(20, map[int]string{3: "x", 10: "y"}),
+			"@@ -1,13 +1,13 @@\n 1\n 2\n-3\n+x\n 4\n 5\n 6\n 7\n 8\n 9\n-10\n+y\n 11\n 12\n 13\n",
+		},
+		{
+			"separate hunks",
+			numberedLines(20, nil),
+			numberedLines(20, map[int]string{3: "x", 11: "y"}),
+			"@@ -1,6 +1,6 @@\n 1\n 2\n-3\n+x\n 4\n 5\n 6\n" +
+				"@@ -8,7 +8,7 @@\n 8\n 9\n 10\n-11\n+y\n 12\n 13\n 14\n",
+		},
+		{
+			"separate hunks with offset",
+			numberedLines(20, nil),
+			"1\n2\nx\ny\n" + strings.TrimPrefix(numberedLines(20, map[int]string{11: "z"}), "1\n2\n3\n"),
+			"@@ -1,6 +1,7 @@\n 1\n 2\n-3\n+x\n+y\n 4\n 5\n 6\n" +
+				"@@ -8,7 +9,7 @@\n 8\n 9\n 10\n-11\n+z\n 12\n 13\n 14\n",
+		},
+	}
+
+	for _, tt := range testcases {
+		t.Run(tt.name, func(t *testing.T) {
+			expect := tt.expect
+			if expect != "" {
+				expect = "--- a\n+++ b\n" + expect
+			}
+			got := unifiedDiff("a", "b", []byte(tt.a), []byte(tt.b))
+			if got != expect {
+				t.Errorf("expected:\n%s\ngot:\n%s", expect, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ignoreFile is the name of the files listing patterns of paths to exclude
+// from the input directory that contains them.
+const ignoreFile = ".webgenignore"
+
+// validPattern returns an error if the glob pattern is malformed.
+func validPattern(pattern string) error {
+	for _, seg := range strings.Split(pattern, "/") {
+		if _, err := path.Match(seg, ""); err != nil {
+			return fmt.Errorf("bad pattern %q", pattern)
+		}
+	}
+	return nil
+}
+
+// matchGlob reports whether the slash-separated relative path name matches
+// the pattern. Each path segment is matched in the syntax of path.Match,
+// except that a "**" segment matches zero or more segments. A pattern
+// without a slash (other than a trailing one) matches the last segment at
+// any depth; a leading slash only anchors the pattern.
+func matchGlob(pattern, name string) bool {
+	pattern = strings.TrimSuffix(pattern, "/")
+	if strings.HasPrefix(pattern, "/") {
+		pattern = pattern[1:]
+	} else if !strings.Contains(pattern, "/") {
+		pattern = "**/" + pattern
+	}
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) != 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
+
+// globRule is a pattern that applies to the paths in dir.
+type globRule struct {
+	dir     string
+	pattern string
+}
+
+// matchRules reports whether p matches any of the rules, relative to the
+// rules' directories.
+func matchRules(rules []globRule, p string) bool {
+	for _, r := range rules {
+		if !isInDir(p, r.dir) {
+			continue
+		}
+		rel, err := filepath.Rel(r.dir, p)
+		if err != nil || rel == "." {
+			continue
+		}
+		if matchGlob(r.pattern, filepath.ToSlash(rel)) {
+			return true
+		}
+	}
+	return false
+}
+
+// readIgnoreFile returns the rules in the ignore file at p, which apply to
+// the paths in its directory. Blank lines and lines starting with "#" are
+// skipped.
+func readIgnoreFile(p string) ([]globRule, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var rules []globRule
+	s := bufio.NewScanner(f)
+	for line := 1; s.Scan(); line++ {
+		pattern := strings.TrimSpace(s.Text())
+		if pattern == "" || strings.HasPrefix(pattern, "#") {
+			continue
+		}
+		if err := validPattern(pattern); err != nil {
+			return nil, fmt.Errorf("%s:%d: %s", p, line, err)
+		}
+		rules = append(rules, globRule{filepath.Dir(p), pattern})
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
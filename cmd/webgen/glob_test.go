@@ -0,0 +1,160 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMatchGlob(t *testing.T) {
+	testcases := []struct {
+		pattern, name string
+		expect        bool
+	}{
+		{"Card.html", "Card.html", true},
+		{"Card.html", "Page.html", false},
+		{"*.html", "Card.html", true},
+		{"*.html", "Card.css", false},
+
+		// A pattern without a slash matches the last segment at any depth.
+		{"*.html", "a/b/Card.html", true},
+		{"drafts", "a/drafts", true},
+		{"a", "a/Card.html", false},
+
+		// A leading slash anchors the pattern.
+		{"/*.html", "Card.html", true},
+		{"/*.html", "a/Card.html", false},
+		{"/drafts", "drafts", true},
+		{"/drafts", "a/drafts", false},
+
+		// So does another slash.
+		{"a/*.html", "a/Card.html", true},
+		{"a/*.html", "b/a/Card.html", false},
+		{"a/*.html", "a/b/Card.html", false},
+
+		// "**" matches zero or more segments.
+		{"**/Card.html", "Card.html", true},
+		{"**/Card.html", "a/b/Card.html", true},
+		{"a/**/*.html", "a/Card.html", true},
+		{"a/**/*.html", "a/b/c/Card.html", true},
+		{"a/**/*.html", "b/Card.html", false},
+		{"a/**", "a/b/Card.html", true},
+		{"a/**/b/*.html", "a/x/b/y/b/Card.html", true},
+		{"a/**/b/*.html", "a/x/b/y/Card.html", false},
+
+		// A directory pattern matches the directory, not the files in it.
+		{"drafts/", "drafts", true},
+		{"drafts/", "a/drafts", true},
+		{"drafts/", "drafts/Card.html", false},
+		{"/a/drafts/", "a/drafts", true},
+
+		// Segments use the syntax of path.Match.
+		{"Card?.html", "Card2.html", true},
+		{"[A-C]*.html", "Card.html", true},
+		{"[A-C]*.html", "Page.html", false},
+		{"*", "a/b", true},
+		{"a*b", "a/b", false},
+	}
+
+	for _, tt := range testcases {
+		t.Run(tt.pattern+" "+tt.name, func(t *testing.T) {
+			if got := matchGlob(tt.pattern, tt.name); got != tt.expect {
+				t.Errorf("expected: %v, got: %v", tt.expect, got)
+			}
+		})
+	}
+}
+
+func TestValidPattern(t *testing.T) {
+	testcases := []struct {
+		pattern string
+		valid   bool
+	}{
+		{"*.html", true},
+		{"a/**/[A-Z]*.html", true},
+		{"/drafts/", true},
+		{"[", false},
+		{"a/[-]/b", false},
+		{`a\`, false},
+	}
+
+	for _, tt := range testcases {
+		t.Run(tt.pattern, func(t *testing.T) {
+			err := validPattern(tt.pattern)
+			if (err == nil) != tt.valid {
+				t.Errorf("expected valid: %v, got error: %v", tt.valid, err)
+			}
+		})
+	}
+}
+
+func TestMatchRules(t *testing.T) {
+	rules := []globRule{
+		{"components", "/drafts/"},
+		{filepath.Join("components", "widgets"), "*_test.html"},
+		{filepath.Join("components", "widgets"), "/old/**"},
+	}
+
+	testcases := []struct {
+		path   string
+		expect bool
+	}{
+		{filepath.Join("components", "drafts"), true},
+		{filepath.Join("components", "widgets", "drafts"), false}, // anchored to components
+		{"drafts", false}, // outside components
+		{filepath.Join("components", "widgets", "Card_test.html"), true},
+		{filepath.Join("components", "widgets", "a", "Card_test.html"), true},
+		{filepath.Join("components", "Card_test.html"), false}, // outside components/widgets
+		{filepath.Join("components", "widgetsCard_test.html"), false},
+		{filepath.Join("components", "widgets", "old", "Card.html"), true},
+		{filepath.Join("components", "old", "Card.html"), false},
+		{filepath.Join("components", "widgets"), false}, // the rules' own directory
+		{filepath.Join("components", "Card.html"), false},
+	}
+
+	for _, tt := range testcases {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := matchRules(rules, tt.path); got != tt.expect {
+				t.Errorf("expected: %v, got: %v", tt.expect, got)
+			}
+		})
+	}
+}
+
+func TestReadIgnoreFile(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "components")
+	p := filepath.Join(dir, ignoreFile)
+
+	t.Run("rules", func(t *testing.T) {
+		writeFile(t, p, "# drafts\n/drafts/\n\n  *_test.html  \n#*.html\n")
+		rules, err := readIgnoreFile(p)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		expect := []globRule{{dir, "/drafts/"}, {dir, "*_test.html"}}
+		if !reflect.DeepEqual(rules, expect) {
+			t.Errorf("expected: %v, got: %v", expect, rules)
+		}
+	})
+
+	t.Run("bad pattern", func(t *testing.T) {
+		writeFile(t, p, "*.html\n\n[\n")
+		_, err := readIgnoreFile(p)
+		if err == nil || !strings.HasSuffix(err.Error(), ignoreFile+`:3: bad pattern "["`) {
+			t.Errorf("expected bad pattern error at line 3, got: %v", err)
+		}
+	})
+}
+
+func writeFile(t *testing.T, p, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(p, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
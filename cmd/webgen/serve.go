@@ -0,0 +1,303 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	eventsPath = "/_webgen/events"
+	reloadPath = "/_webgen/reload.js"
+)
+
+// reloadScript is injected into HTML pages served by webgen serve. It
+// reloads the page, or only its stylesheets, when the server says so.
+const reloadScript = `(function() {
+	var events = new EventSource("` + eventsPath + `");
+	events.addEventListener("reload", function() {
+		location.reload();
+	});
+	events.addEventListener("css", function() {
+		var links = document.querySelectorAll('link[rel="stylesheet"]');
+		for (var i = 0; i < links.length; i++) {
+			var u = new URL(links[i].href);
+			u.searchParams.set("webgen", Date.now());
+			links[i].href = u.toString();
+		}
+	});
+})();
+`
+
+var reloadTag = []byte(`<script src="` + reloadPath + `"></script>`)
+
+// serve serves the --dir directory over HTTP, and generates output (and
+// builds the wasm binary, with --build) whenever files change. Browsers
+// with a page from the directory open are told to reload the page, or only
+// its stylesheets if only CSS output changed. serve returns only if the
+// server fails, such as when it cannot listen on --addr.
+func serve(targets []*target, interval time.Duration) error {
+	b := &broker{clients: make(map[chan string]struct{})}
+	mux := http.NewServeMux()
+	mux.Handle(eventsPath, b)
+	mux.HandleFunc(reloadPath, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/javascript; charset=utf-8")
+		w.Write([]byte(reloadScript))
+	})
+	h := &staticHandler{dir: fDir, files: http.FileServer(http.Dir(fDir))}
+	h.wasmExec, h.wasmExecErr = wasmExecPath()
+	mux.Handle("/", h)
+
+	ln, err := net.Listen("tcp", fAddr)
+	if err != nil {
+		return err
+	}
+	stderr.Printf("serving %s on http://%s", fDir, ln.Addr())
+	errc := make(chan error, 1)
+	go func() {
+		errc <- http.Serve(ln, mux)
+	}()
+
+	var watched []string
+	var changed []string // paths changed since the previous run
+	for first := true; ; first = false {
+		res, err := runTargets(targets)
+		if err != nil {
+			stderr.Printf("%s", err)
+			watched = union(watched, res.deps)
+		} else {
+			watched = res.deps
+		}
+
+		var event string
+		switch {
+		case err != nil:
+			// Keep the page as is, until the error is fixed.
+		case res.changed["views"] || res.changed["manifest"] || anyStatic(changed):
+			event = "reload"
+		case res.changed["css"] || res.changed["css source map"]:
+			event = "css"
+		}
+
+		if fBuild != "" && err == nil && (first || res.changed["views"] || anyGo(changed)) {
+			event = "reload"
+			if err := buildWasm(); err != nil {
+				stderr.Printf("%s", err)
+				event = ""
+			}
+		}
+		if event != "" {
+			b.send(event)
+		}
+
+		select {
+		case err := <-errc:
+			return err
+		default:
+		}
+
+		changed = waitForChange(func() []string {
+			paths := union(watchedPaths(targets, watched), walkFiles(fDir, ""))
+			if fBuild != "" {
+				paths = union(paths, walkFiles(fBuild, ".go"))
+			}
+			return paths
+		}, interval)
+	}
+}
+
+// anyStatic reports whether any of the paths is in the served directory,
+// other than Go files.
+func anyStatic(paths []string) bool {
+	for _, p := range paths {
+		if filepath.Ext(p) != ".go" && isInDir(p, fDir) {
+			return true
+		}
+	}
+	return false
+}
+
+// anyGo reports whether any of the paths is a Go file.
+func anyGo(paths []string) bool {
+	for _, p := range paths {
+		if filepath.Ext(p) == ".go" {
+			return true
+		}
+	}
+	return false
+}
+
+func isInDir(p, dir string) bool {
+	rel, err := filepath.Rel(dir, p)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// walkFiles returns the paths of the files in dir, recursively, that have
+// the extension ext, or all files if ext is empty. Hidden files and
+// directories are skipped.
+func walkFiles(dir, ext string) []string {
+	var paths []string
+	filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil // ignore; the file may have been removed
+		}
+		if p != dir && strings.HasPrefix(info.Name(), ".") {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !info.IsDir() && (ext == "" || filepath.Ext(p) == ext) {
+			paths = append(paths, p)
+		}
+		return nil
+	})
+	return paths
+}
+
+// buildWasm builds the --build package for js/wasm, writing the binary to
+// the --wasm file.
+func buildWasm() error {
+	stderr.Printf("building %s", fBuild)
+	cmd := exec.Command("go", "build", "-o", fWasm, fBuild)
+	cmd.Env = append(os.Environ(), "GOOS=js", "GOARCH=wasm")
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("build %s: %s", fBuild, err)
+	}
+	return nil
+}
+
+// broker sends server-sent events to the connected browsers.
+type broker struct {
+	mu      sync.Mutex
+	clients map[chan string]struct{}
+}
+
+// send sends the event to every connected browser.
+func (b *broker) send(event string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for c := range b.clients {
+		select {
+		case c <- event:
+		default: // the browser has an event to receive already
+		}
+	}
+}
+
+func (b *broker) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	c := make(chan string, 1)
+	b.mu.Lock()
+	b.clients[c] = struct{}{}
+	b.mu.Unlock()
+	defer func() {
+		b.mu.Lock()
+		delete(b.clients, c)
+		b.mu.Unlock()
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event := <-c:
+			fmt.Fprintf(w, "event: %s\ndata:\n\n", event)
+			flusher.Flush()
+		}
+	}
+}
+
+// staticHandler serves the files in dir. It injects the reload script into
+// HTML pages, and serves wasm_exec.js from the Go installation unless dir
+// has one.
+type staticHandler struct {
+	dir   string
+	files http.Handler
+
+	// Path of wasm_exec.js in the Go installation, or the error finding it.
+	wasmExec    string
+	wasmExecErr error
+}
+
+func (h *staticHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "no-cache")
+
+	p := path.Clean("/" + r.URL.Path)
+	name := filepath.Join(h.dir, filepath.FromSlash(p))
+	info, err := os.Stat(name)
+	if err == nil && info.IsDir() {
+		name = filepath.Join(name, "index.html")
+		info, err = os.Stat(name)
+	}
+
+	switch {
+	case os.IsNotExist(err) && p == "/wasm_exec.js":
+		if h.wasmExecErr != nil {
+			http.Error(w, h.wasmExecErr.Error(), http.StatusNotFound)
+			return
+		}
+		http.ServeFile(w, r, h.wasmExec)
+	case err == nil && filepath.Ext(name) == ".html":
+		b, err := ioutil.ReadFile(name)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(injectReloadScript(b))
+	default:
+		h.files.ServeHTTP(w, r)
+	}
+}
+
+// injectReloadScript adds the reload script to the HTML page, before
+// </body> if present.
+func injectReloadScript(page []byte) []byte {
+	i := bytes.LastIndex(bytes.ToLower(page), []byte("</body>"))
+	if i == -1 {
+		return append(page, reloadTag...)
+	}
+	var b bytes.Buffer
+	b.Write(page[:i])
+	b.Write(reloadTag)
+	b.Write(page[i:])
+	return b.Bytes()
+}
+
+// wasmExecPath returns the path of wasm_exec.js in the Go installation.
+func wasmExecPath() (string, error) {
+	out, err := exec.Command("go", "env", "GOROOT").Output()
+	if err != nil {
+		return "", fmt.Errorf("go env GOROOT: %s", err)
+	}
+	goroot := strings.TrimSpace(string(out))
+	for _, dir := range []string{"lib", "misc"} {
+		p := filepath.Join(goroot, dir, "wasm", "wasm_exec.js")
+		if _, err := os.Stat(p); err == nil {
+			return p, nil
+		}
+	}
+	return "", fmt.Errorf("wasm_exec.js not found in %s", goroot)
+}
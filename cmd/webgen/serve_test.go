@@ -0,0 +1,190 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestInjectReloadScript(t *testing.T) {
+	tag := string(reloadTag)
+	testcases := []struct {
+		name, in, expect string
+	}{
+		{"body", "<html><body><p>x</p></body></html>", "<html><body><p>x</p>" + tag + "</body></html>"},
+		{"upper case", "<HTML><BODY>x</BODY></HTML>", "<HTML><BODY>x" + tag + "</BODY></HTML>"},
+		{"mixed case", "<body>x</Body>\n", "<body>x" + tag + "</Body>\n"},
+		{"last body", "<body><pre>&lt;/body></pre></body>", "<body><pre>&lt;/body></pre>" + tag + "</body>"},
+		{"no body", "<p>x</p>\n", "<p>x</p>\n" + tag},
+		{"empty", "", tag},
+	}
+
+	for _, tt := range testcases {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(injectReloadScript([]byte(tt.in))); got != tt.expect {
+				t.Errorf("expected: %q, got: %q", tt.expect, got)
+			}
+		})
+	}
+}
+
+func TestStaticHandler(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "index.html"), "<body>home</body>")
+	writeFile(t, filepath.Join(dir, "docs", "index.html"), "<p>docs</p>")
+	writeFile(t, filepath.Join(dir, "app.css"), "body {}")
+	goroot := t.TempDir()
+	writeFile(t, filepath.Join(goroot, "wasm_exec.js"), "// from GOROOT")
+
+	h := &staticHandler{
+		dir:      dir,
+		files:    http.FileServer(http.Dir(dir)),
+		wasmExec: filepath.Join(goroot, "wasm_exec.js"),
+	}
+
+	testcases := []struct {
+		name   string
+		path   string
+		status int
+		body   string
+	}{
+		{"index", "/", http.StatusOK, "<body>home" + string(reloadTag) + "</body>"},
+		{"index.html", "/index.html", http.StatusOK, "<body>home" + string(reloadTag) + "</body>"},
+		{"subdirectory index", "/docs/", http.StatusOK, "<p>docs</p>" + string(reloadTag)},
+		{"subdirectory without slash", "/docs", http.StatusOK, "<p>docs</p>" + string(reloadTag)},
+		{"css", "/app.css", http.StatusOK, "body {}"},
+		{"not found", "/missing.html", http.StatusNotFound, "404 page not found\n"},
+		{"wasm_exec.js from GOROOT", "/wasm_exec.js", http.StatusOK, "// from GOROOT"},
+	}
+
+	for _, tt := range testcases {
+		t.Run(tt.name, func(t *testing.T) {
+			status, body := get(t, h, tt.path)
+			if status != tt.status || body != tt.body {
+				t.Errorf("expected: %d %q, got: %d %q", tt.status, tt.body, status, body)
+			}
+		})
+	}
+
+	t.Run("wasm_exec.js in dir", func(t *testing.T) {
+		writeFile(t, filepath.Join(dir, "wasm_exec.js"), "// from dir")
+		defer removeFile(t, filepath.Join(dir, "wasm_exec.js"))
+		status, body := get(t, h, "/wasm_exec.js")
+		if status != http.StatusOK || body != "// from dir" {
+			t.Errorf("expected wasm_exec.js from dir, got: %d %q", status, body)
+		}
+	})
+
+	t.Run("wasm_exec.js not found", func(t *testing.T) {
+		h := &staticHandler{
+			dir:         dir,
+			files:       http.FileServer(http.Dir(dir)),
+			wasmExecErr: errors.New("wasm_exec.js not found in /go"),
+		}
+		status, body := get(t, h, "/wasm_exec.js")
+		if status != http.StatusNotFound || body != "wasm_exec.js not found in /go\n" {
+			t.Errorf("expected not found, got: %d %q", status, body)
+		}
+	})
+}
+
+// get returns the status code and body of the handler's response to a GET
+// request for the path.
+func get(t *testing.T, h http.Handler, path string) (int, string) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+	if got := w.Header().Get("Cache-Control"); got != "no-cache" {
+		t.Errorf("%s: expected Cache-Control: no-cache, got: %q", path, got)
+	}
+	return w.Code, w.Body.String()
+}
+
+func TestBroker(t *testing.T) {
+	b := &broker{clients: make(map[chan string]struct{})}
+	srv := httptest.NewServer(b)
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	const n = 3
+	var streams []*bufio.Reader
+	for i := 0; i < n; i++ {
+		req, err := http.NewRequestWithContext(ctx, "GET", srv.URL, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+			t.Errorf("expected Content-Type: text/event-stream, got: %q", ct)
+		}
+		streams = append(streams, bufio.NewReader(resp.Body))
+	}
+	// The headers are flushed after the client is added.
+	b.mu.Lock()
+	if len(b.clients) != n {
+		t.Errorf("expected %d clients, got: %d", n, len(b.clients))
+	}
+	b.mu.Unlock()
+
+	for _, event := range []string{"reload", "css"} {
+		b.send(event)
+		for i, s := range streams {
+			got := readEvent(t, s)
+			if expect := "event: " + event + "\ndata:\n\n"; got != expect {
+				t.Errorf("client %d: expected: %q, got: %q", i, expect, got)
+			}
+		}
+	}
+
+	// A disconnected client is removed.
+	cancel()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		b.mu.Lock()
+		left := len(b.clients)
+		b.mu.Unlock()
+		if left == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected no clients after disconnecting, got: %d", left)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// readEvent reads a server-sent event, which ends with an empty line.
+func readEvent(t *testing.T, r *bufio.Reader) string {
+	t.Helper()
+	var b strings.Builder
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatal(err)
+		}
+		b.WriteString(line)
+		if line == "\n" {
+			return b.String()
+		}
+	}
+}
+
+func removeFile(t *testing.T, p string) {
+	t.Helper()
+	if err := os.Remove(p); err != nil {
+		t.Fatal(err)
+	}
+}
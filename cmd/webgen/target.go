@@ -0,0 +1,395 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/littleroot/webgen"
+)
+
+// target is a generation target: input files and directories, and how to
+// generate output from them. A target comes from the flags, or from a
+// config file, whose keys are the names of the corresponding flags.
+type target struct {
+	Name           string   `json:"name"`
+	Inputs         []string `json:"inputs"`
+	Include        []string `json:"include"`
+	Exclude        []string `json:"exclude"`
+	Package        string   `json:"package"`
+	Root           string   `json:"root"`
+	OutViews       string   `json:"outviews"`
+	OutDir         string   `json:"outdir"`
+	OutCSS         string   `json:"outcss"`
+	OutCSSMap      string   `json:"outcss-map"`
+	EmbedCSS       bool     `json:"embed-css"`
+	MinifyCSS      bool     `json:"minify-css"`
+	CSSOrder       []string `json:"css-order"`
+	Backend        string   `json:"backend"`
+	Hydrate        bool     `json:"hydrate"`
+	CloneTemplates bool     `json:"clone-templates"`
+	Compact        bool     `json:"compact"`
+	ImportPath     string   `json:"import-path"`
+	OutManifest    string   `json:"outmanifest"`
+	Manifests      []string `json:"manifests"`
+	PackagePerDir  bool     `json:"package-per-dir"`
+	Naming         string   `json:"naming"`
+	ExportNames    bool     `json:"export-names"`
+	Depfile        string   `json:"depfile"`
+
+	backend webgen.Backend        // from Backend; set by validate
+	naming  webgen.NamingStrategy // from Naming; set by validate
+}
+
+// targetFromFlags returns the target described by the flags, with inputs
+// args.
+func targetFromFlags(args []string) *target {
+	t := &target{
+		Inputs:         args,
+		Package:        fPackageName,
+		Root:           fRoot,
+		OutViews:       fOutViews,
+		OutDir:         fOutDir,
+		OutCSS:         fOutCSS,
+		OutCSSMap:      fOutCSSMap,
+		EmbedCSS:       fEmbedCSS,
+		MinifyCSS:      fMinifyCSS,
+		Backend:        fBackend,
+		Hydrate:        fHydrate,
+		CloneTemplates: fClone,
+		Compact:        fCompact,
+		ImportPath:     fImportPath,
+		OutManifest:    fOutManifest,
+		PackagePerDir:  fPkgPerDir,
+		Naming:         fNaming,
+		ExportNames:    fExportNames,
+		Depfile:        fDepfile,
+	}
+	if fCSSOrder != "" {
+		t.CSSOrder = strings.Split(fCSSOrder, ",")
+	}
+	if fManifests != "" {
+		t.Manifests = strings.Split(fManifests, ",")
+	}
+	if fInclude != "" {
+		t.Include = strings.Split(fInclude, ",")
+	}
+	if fExclude != "" {
+		t.Exclude = strings.Split(fExclude, ",")
+	}
+	return t
+}
+
+// validate checks the target's settings, and the settings required by the
+// command: serve, or --check.
+func (t *target) validate(serveCmd bool) error {
+	var ok bool
+	t.backend, ok = backends[t.Backend]
+	if !ok {
+		return fmt.Errorf("unknown backend %q", t.Backend)
+	}
+	t.naming, ok = namingStrategies[t.Naming]
+	if !ok {
+		return fmt.Errorf("unknown naming strategy %q", t.Naming)
+	}
+	for _, patterns := range [][]string{t.Include, t.Exclude} {
+		for _, pattern := range patterns {
+			if err := validPattern(pattern); err != nil {
+				return err
+			}
+		}
+	}
+
+	hasViewsFile := t.OutViews != "" || t.OutDir != ""
+	switch {
+	case t.EmbedCSS && t.backend == webgen.BackendSSR:
+		return errors.New("--embed-css cannot be used with --backend=ssr")
+	case t.CloneTemplates && t.Compact:
+		return errors.New("--clone-templates cannot be used with --compact")
+	case serveCmd && !hasViewsFile:
+		return errors.New("serve requires --outviews or --outdir")
+	case t.Depfile != "" && !hasViewsFile:
+		return errors.New("--depfile requires --outviews or --outdir")
+	case fCheck && !hasViewsFile:
+		return errors.New("--check requires --outviews or --outdir")
+	case t.OutViews != "" && t.OutDir != "":
+		return errors.New("--outviews cannot be used with --outdir")
+	case t.PackagePerDir && (t.ImportPath == "" || !hasViewsFile):
+		return errors.New("--package-per-dir requires --import-path, and --outviews or --outdir")
+	case t.PackagePerDir && t.OutManifest != "":
+		return errors.New("--outmanifest cannot be used with --package-per-dir")
+	case !t.PackagePerDir && (t.ImportPath == "") != (t.OutManifest == ""):
+		return errors.New("--import-path and --outmanifest must be used together")
+	case t.EmbedCSS && t.OutCSS != "":
+		return errors.New("--outcss cannot be used with --embed-css")
+	case t.OutCSSMap != "" && t.OutCSS == "":
+		return errors.New("--outcss-map requires --outcss")
+	}
+	return nil
+}
+
+// options returns the generation options for the target.
+func (t *target) options() (webgen.Options, error) {
+	var cssMapURL, cssMapDir string
+	if t.OutCSSMap != "" {
+		cssMapDir = filepath.Dir(t.OutCSSMap)
+		// Reference the source map relative to the CSS file.
+		rel, err := filepath.Rel(filepath.Dir(t.OutCSS), t.OutCSSMap)
+		if err != nil {
+			return webgen.Options{}, fmt.Errorf("source map path: %s", err)
+		}
+		cssMapURL = filepath.ToSlash(rel)
+	}
+
+	return webgen.Options{
+		Package:             t.Package,
+		Root:                t.Root,
+		EmbedCSS:            t.EmbedCSS,
+		MinifyCSS:           t.MinifyCSS,
+		CSSSourceMapURL:     cssMapURL,
+		CSSSourceMapDir:     cssMapDir,
+		CSSOrder:            t.CSSOrder,
+		Backend:             t.backend,
+		Hydrate:             t.Hydrate,
+		CloneTemplates:      t.CloneTemplates,
+		CompactConstructors: t.Compact,
+		ImportPath:          t.ImportPath,
+		Manifests:           t.Manifests,
+		PackagePerDir:       t.PackagePerDir,
+		FilePerComponent:    t.OutDir != "",
+		Naming:              t.naming,
+		ExportNames:         t.ExportNames,
+	}, nil
+}
+
+// runResult is the result of run.
+type runResult struct {
+	// deps lists the paths of the files that the output depends on, or,
+	// if generation fails, the input files and the files read before the
+	// error.
+	deps []string
+
+	// changed has the kinds of the written outputs whose contents changed.
+	changed map[string]bool
+}
+
+// run generates the output for the target, and writes it (or checks it,
+// with --check).
+func (t *target) run() (runResult, error) {
+	opts, err := t.options()
+	if err != nil {
+		return runResult{}, err
+	}
+	inFiles, err := t.inputFiles()
+	if err != nil {
+		return runResult{}, err
+	}
+
+	out, err := webgen.GenerateOutput(inFiles, opts)
+	if err != nil {
+		return runResult{deps: union(inFiles, out.Deps)}, err
+	}
+
+	res := runResult{deps: out.Deps, changed: make(map[string]bool)}
+	outputs := t.outputFiles(out)
+	if fCheck {
+		return res, check(os.Stdout, outputs)
+	}
+	for _, f := range outputs {
+		changed, err := f.write()
+		if err != nil {
+			return res, fmt.Errorf("write output %s: %s", f.kind, err)
+		}
+		if changed {
+			res.changed[f.kind] = true
+		}
+	}
+	if t.Depfile != "" {
+		f := outputFile{kind: "depfile", path: t.Depfile, data: depfile(outputs, out.Deps)}
+		if _, err := f.write(); err != nil {
+			return res, fmt.Errorf("write output %s: %s", f.kind, err)
+		}
+	}
+	return res, nil
+}
+
+// runTargets runs the targets. An error does not stop other targets from
+// running; the errors of all targets are returned together.
+func runTargets(targets []*target) (runResult, error) {
+	res := runResult{changed: make(map[string]bool)}
+	var errs []string
+	for _, t := range targets {
+		r, err := t.run()
+		res.deps = union(res.deps, r.deps)
+		for kind := range r.changed {
+			res.changed[kind] = true
+		}
+		if err != nil {
+			if t.Name != "" {
+				err = fmt.Errorf("target %s: %s", t.Name, err)
+			}
+			errs = append(errs, err.Error())
+		}
+	}
+	if len(errs) != 0 {
+		return res, errors.New(strings.Join(errs, "\n"))
+	}
+	return res, nil
+}
+
+// inputFiles returns the target's input files.
+func (t *target) inputFiles() ([]string, error) {
+	inFiles, _, err := t.walkInputs()
+	return inFiles, err
+}
+
+// walkInputs returns the target's input files, and the ignore files read
+// to find them. Input directories are walked recursively for *.html files
+// that match an include pattern, if there are any, and that match no
+// exclude pattern or pattern in an ignore file; directories that match are
+// skipped. Input files given explicitly are always used.
+func (t *target) walkInputs() (inFiles, ignoreFiles []string, err error) {
+	dedup := make(map[string]struct{})
+	maybeAdd := func(p string) {
+		if _, ok := dedup[p]; ok {
+			return // already present
+		}
+		dedup[p] = struct{}{}
+		inFiles = append(inFiles, p)
+	}
+
+	for _, a := range t.Inputs {
+		info, err := os.Stat(a)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !info.IsDir() {
+			// assume it's a file.
+			// we also don't check for a .html extension since this is an
+			// explicitly provided command line argument.
+			maybeAdd(a)
+			continue
+		}
+
+		include := rulesIn(a, t.Include)
+		exclude := rulesIn(a, t.Exclude)
+		if err := filepath.Walk(a, func(p string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if matchRules(exclude, p) {
+				if info.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if info.IsDir() {
+				ip := filepath.Join(p, ignoreFile)
+				rules, err := readIgnoreFile(ip)
+				switch {
+				case err == nil:
+					ignoreFiles = append(ignoreFiles, ip)
+					exclude = append(exclude, rules...)
+				case !os.IsNotExist(err):
+					return err
+				}
+				return nil // will be handled by recursive walk
+			}
+			if filepath.Ext(p) != ".html" {
+				return nil
+			}
+			if len(include) != 0 && !matchRules(include, p) {
+				return nil
+			}
+			maybeAdd(p)
+			return nil
+		}); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return inFiles, ignoreFiles, nil
+}
+
+// rulesIn returns rules for the patterns in dir.
+func rulesIn(dir string, patterns []string) []globRule {
+	rules := make([]globRule, len(patterns))
+	for i, pattern := range patterns {
+		rules[i] = globRule{dir, pattern}
+	}
+	return rules
+}
+
+// staleFiles returns outputs that remove the component files in the
+// directories (but not their subdirectories) that are not among outputs,
+// such as those of removed or renamed components.
+func staleFiles(dirs []string, outputs []outputFile) []outputFile {
+	generated := make(map[string]bool)
+	for _, f := range outputs {
+		generated[filepath.Clean(f.path)] = true
+	}
+
+	var stale []outputFile
+	for _, dir := range dirs {
+		infos, err := ioutil.ReadDir(dir)
+		if err != nil {
+			continue // ignore; dir may not exist yet
+		}
+		for _, info := range infos {
+			p := filepath.Join(dir, info.Name())
+			if !info.IsDir() && strings.HasSuffix(p, componentFileSuffix) && !generated[p] {
+				stale = append(stale, outputFile{kind: "views", path: p, remove: true})
+			}
+		}
+	}
+	return stale
+}
+
+// componentFileSuffix is the suffix of the names of the files that
+// webgen.Output.Files has for components.
+const componentFileSuffix = "_webgen.go"
+
+// outputFiles returns the target's outputs to write.
+func (t *target) outputFiles(out *webgen.Output) []outputFile {
+	var files []outputFile
+	var viewsDirs []string // directories of the component files
+	addViews := func(dir string, views []webgen.File) {
+		viewsDirs = union(viewsDirs, []string{dir})
+		for _, f := range views {
+			files = append(files, outputFile{kind: "views", path: filepath.Join(dir, f.Name), data: f.Views})
+		}
+	}
+
+	switch {
+	case t.PackagePerDir && t.OutDir != "":
+		viewsDirs = []string{t.OutDir}
+		for _, pkg := range out.Packages {
+			addViews(filepath.Join(t.OutDir, pkg.Dir), pkg.Files)
+		}
+	case t.PackagePerDir:
+		for _, pkg := range out.Packages {
+			p := filepath.Join(filepath.Dir(t.OutViews), pkg.Dir, filepath.Base(t.OutViews))
+			files = append(files, outputFile{kind: "views", path: p, data: pkg.Views})
+		}
+	case t.OutDir != "":
+		addViews(t.OutDir, out.Files)
+	default:
+		files = append(files, outputFile{kind: "views", path: t.OutViews, data: out.Views})
+	}
+	if t.OutDir != "" {
+		files = append(files, staleFiles(viewsDirs, files)...)
+	}
+	if !t.EmbedCSS {
+		files = append(files, outputFile{kind: "css", path: t.OutCSS, data: out.CSS})
+	}
+	if t.OutCSSMap != "" {
+		files = append(files, outputFile{kind: "css source map", path: t.OutCSSMap, data: out.CSSSourceMap})
+	}
+	if t.OutManifest != "" {
+		files = append(files, outputFile{kind: "manifest", path: t.OutManifest, data: out.Manifest})
+	}
+	return files
+}
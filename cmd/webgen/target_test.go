@@ -0,0 +1,112 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/littleroot/webgen"
+)
+
+func TestOutputFilesStale(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{
+		"webgen.go",
+		"Page_webgen.go",
+		"OldPage_webgen.go", // renamed to Page.html
+		"notes.go",
+		filepath.Join("ui", "Card_webgen.go"),
+		filepath.Join("ui", "OldCard_webgen.go"),  // renamed to Card.html
+		filepath.Join("other", "X_webgen.go"),     // not a generated package
+		filepath.Join("ui", "sub", "Y_webgen.go"), // not a generated package
+	} {
+		writeFile(t, filepath.Join(dir, name), "package views\n")
+	}
+
+	files := []webgen.File{{Name: "webgen.go"}, {Name: "Page_webgen.go"}}
+	uiFiles := []webgen.File{{Name: "webgen.go"}, {Name: "Card_webgen.go"}}
+
+	testcases := []struct {
+		name   string
+		target target
+		out    *webgen.Output
+		expect []string
+	}{
+		{
+			name:   "outdir",
+			target: target{OutDir: dir, EmbedCSS: true},
+			out:    &webgen.Output{Files: files},
+			expect: []string{"OldPage_webgen.go"},
+		},
+		{
+			name:   "package per dir",
+			target: target{OutDir: dir, PackagePerDir: true, EmbedCSS: true},
+			out: &webgen.Output{Packages: []webgen.Package{
+				{Dir: ".", Files: files},
+				{Dir: "ui", Files: uiFiles},
+			}},
+			expect: []string{"OldPage_webgen.go", filepath.Join("ui", "OldCard_webgen.go")},
+		},
+		{
+			name:   "package per dir without root package",
+			target: target{OutDir: dir, PackagePerDir: true, EmbedCSS: true},
+			out: &webgen.Output{Packages: []webgen.Package{
+				{Dir: "ui", Files: uiFiles},
+			}},
+			expect: []string{"OldPage_webgen.go", "Page_webgen.go", filepath.Join("ui", "OldCard_webgen.go")},
+		},
+		{
+			name:   "outviews",
+			target: target{OutViews: filepath.Join(dir, "views.go"), EmbedCSS: true},
+			out:    &webgen.Output{},
+			expect: nil,
+		},
+	}
+
+	for _, tt := range testcases {
+		t.Run(tt.name, func(t *testing.T) {
+			var removed []string
+			for _, f := range tt.target.outputFiles(tt.out) {
+				if f.remove {
+					rel, err := filepath.Rel(dir, f.path)
+					if err != nil {
+						t.Fatal(err)
+					}
+					removed = append(removed, rel)
+				}
+			}
+			sort.Strings(removed)
+			if !reflect.DeepEqual(removed, tt.expect) {
+				t.Errorf("expected removed: %v, got: %v", tt.expect, removed)
+			}
+		})
+	}
+
+	t.Run("write", func(t *testing.T) {
+		tg := target{OutDir: dir, PackagePerDir: true, EmbedCSS: true}
+		out := &webgen.Output{Packages: []webgen.Package{
+			{Dir: ".", Files: files},
+			{Dir: "ui", Files: uiFiles},
+		}}
+		for _, f := range tg.outputFiles(out) {
+			if _, err := f.write(); err != nil {
+				t.Fatal(err)
+			}
+		}
+		for name, exists := range map[string]bool{
+			"Page_webgen.go":                          true,
+			"OldPage_webgen.go":                       false,
+			"notes.go":                                true,
+			filepath.Join("ui", "OldCard_webgen.go"):  false,
+			filepath.Join("other", "X_webgen.go"):     true,
+			filepath.Join("ui", "sub", "Y_webgen.go"): true,
+		} {
+			_, err := os.Stat(filepath.Join(dir, name))
+			if (err == nil) != exists {
+				t.Errorf("%s: expected exists: %v, got error: %v", name, exists, err)
+			}
+		}
+	})
+}
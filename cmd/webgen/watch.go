@@ -0,0 +1,109 @@
+package main
+
+import (
+	"os"
+	"time"
+)
+
+// fileState is the state of a watched file, which is compared to detect
+// changes.
+type fileState struct {
+	exists  bool
+	size    int64
+	modTime time.Time
+}
+
+// watch generates output for the targets whenever an input file, or a file
+// that the output depends on, changes. Files are polled every interval,
+// and input directories are walked again to find new files. Errors are
+// printed without exiting. watch does not return.
+func watch(targets []*target, interval time.Duration) {
+	var watched []string
+	for {
+		res, err := runTargets(targets)
+		if err != nil {
+			stderr.Printf("%s", err)
+			// Keep watching the dependencies of the last successful run,
+			// so that fixing an included file regenerates output.
+			watched = union(watched, res.deps)
+		} else {
+			watched = res.deps
+		}
+		stderr.Printf("watching %d files for changes", len(watched))
+		waitForChange(func() []string {
+			return watchedPaths(targets, watched)
+		}, interval)
+	}
+}
+
+// watchedPaths returns the watched files, and the current input files and
+// ignore files of the targets.
+func watchedPaths(targets []*target, watched []string) []string {
+	for _, t := range targets {
+		if inFiles, ignoreFiles, err := t.walkInputs(); err == nil {
+			watched = union(watched, union(inFiles, ignoreFiles))
+		}
+	}
+	return watched
+}
+
+// waitForChange returns the paths that changed, when any of the paths
+// returned by paths changes.
+func waitForChange(paths func() []string, interval time.Duration) []string {
+	prev := snapshot(paths())
+	for {
+		time.Sleep(interval)
+		next := snapshot(paths())
+		if changed := changedPaths(prev, next); len(changed) != 0 {
+			return changed
+		}
+	}
+}
+
+// snapshot returns the state of the files, by path.
+func snapshot(paths []string) map[string]fileState {
+	m := make(map[string]fileState, len(paths))
+	for _, p := range paths {
+		info, err := os.Stat(p)
+		if err != nil {
+			m[p] = fileState{}
+			continue
+		}
+		m[p] = fileState{exists: true, size: info.Size(), modTime: info.ModTime()}
+	}
+	return m
+}
+
+// changedPaths returns the paths whose state differs in the snapshots,
+// including paths that are in only one of them.
+func changedPaths(a, b map[string]fileState) []string {
+	var changed []string
+	for p, s := range a {
+		t, ok := b[p]
+		if !ok || s.exists != t.exists || s.size != t.size || !s.modTime.Equal(t.modTime) {
+			changed = append(changed, p)
+		}
+	}
+	for p := range b {
+		if _, ok := a[p]; !ok {
+			changed = append(changed, p)
+		}
+	}
+	return changed
+}
+
+// union returns the paths in a or b, without duplicates.
+func union(a, b []string) []string {
+	seen := make(map[string]struct{}, len(a)+len(b))
+	var u []string
+	for _, list := range [][]string{a, b} {
+		for _, p := range list {
+			if _, ok := seen[p]; ok {
+				continue
+			}
+			seen[p] = struct{}{}
+			u = append(u, p)
+		}
+	}
+	return u
+}
@@ -1,12 +1,17 @@
 package main
 
 import (
+	"bytes"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"log"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/littleroot/webgen"
 )
@@ -21,21 +26,152 @@ var (
 )
 
 const usage = `
-Generate webapi package Go code for the js/wasm architecture from components
-defined in HTML.
+Generate Go code for the js/wasm architecture from components defined in
+HTML, using the webapi or syscall/js packages, or Go code that renders the
+components' HTML on a server.
 
 Usage:
-   webgen [--outcss=<file>] [--outviews=<file>] [--package=<name>]
-          [--root=<dir>] (<input-file> | <input-directory>)...
+   webgen [--outcss=<file> [--outcss-map=<file>] | --embed-css]
+          [--minify-css] [--css-order=<file>,...]
+          [--outviews=<file> | --outdir=<dir>]
+          [--package=<name>] [--root=<dir>] [--backend=<name>] [--hydrate]
+          [--clone-templates | --compact]
+          [--import-path=<path> [--outmanifest=<file> | --package-per-dir]]
+          [--manifests=<file>,...] [--naming=<strategy>] [--export-names]
+          [--include=<pattern>,...] [--exclude=<pattern>,...]
+          [--watch [--watch-interval=<duration>]] [--depfile=<file>]
+          (<input-file> | <input-directory>)...
+   webgen --check [<flags>] (<input-file> | <input-directory>)...
+   webgen [--config=<file>] [--target=<name>,...] [--check | --watch]
+   webgen serve [--addr=<addr>] [--dir=<dir>]
+          [--build=<package> [--wasm=<file>]]
+          [<flags>] (<input-file> | <input-directory>)...
    webgen (-h | --help)
 
 Flags:
    -h --help           Print help and exit
+   --backend=<name>    Kind of views output: "webapi", "syscalljs", or
+                       "tinygo" for js/wasm, or "ssr" for server-side
+                       rendering (default: "webapi")
+   --check             Do not write output; instead, print a unified diff of
+                       each output file that differs from the generated
+                       output, and exit with status 1 if any file differs.
+                       Requires --outviews or --outdir
+   --clone-templates   Generate constructors that clone a <template> of the
+                       component's markup instead of creating each element
+   --compact           Generate constructors as tables of build operations,
+                       for smaller generated code
+   --config=<file>     Generate the targets in the config file (default:
+                       "webgen.json", if no input files or directories are
+                       given)
+   --css-order=<file>,...
+                       Order the CSS of the listed component files as listed
+                       (by default, CSS is ordered so that components come
+                       after the components they include, and then by path)
+   --depfile=<file>    Write a Make-style depfile, in which the output files
+                       depend on the input files, the files they include or
+                       link, and manifests; requires --outviews or --outdir
+   --embed-css         Embed CSS in the views output; constructors inject a
+                       component's CSS into the document on first use
+   --exclude=<pattern>,...
+                       Skip files and directories in input directories that
+                       match any of the patterns (see Patterns). Patterns
+                       can also be listed, one per line, in a .webgenignore
+                       file in an input directory or its subdirectories,
+                       relative to the file's directory
+   --export-names      Export the types of all components, even if their file
+                       names start with a lowercase letter
+   --hydrate           Mark server-rendered roots (ssr), or generate
+                       Hydrate* functions that attach to server-rendered DOM
+                       (webapi)
+   --include=<pattern>,...
+                       Use only the *.html files in input directories that
+                       match any of the patterns (see Patterns); input files
+                       given explicitly are always used
+   --import-path=<path>
+                       Import path of the output package, for the manifest
+                       written by --outmanifest, or the import path prefix
+                       of the output packages with --package-per-dir
+   --manifests=<file>,...
+                       Manifests of other packages, whose components can be
+                       included with <include component="<path>.<type>">
+   --minify-css        Minify CSS output (or embedded CSS, with --embed-css)
+   --naming=<strategy> Naming of component types: "base" for the file name,
+                       or "dir-prefix" for the file name prefixed by the
+                       directories relative to --root (default: "base")
    --outcss=<file>     Write CSS output to specified file instead of stdout
+   --outcss-map=<file> Write a source map for the CSS output to specified
+                       file; requires --outcss
+   --outdir=<dir>      Write views output to a file per component in
+                       specified directory, named after the component type
+                       (for example, "Card_webgen.go"), and a shared
+                       "webgen.go" file, instead of to stdout; removes
+                       other "*_webgen.go" files in the directory
+   --outmanifest=<file>
+                       Write a manifest of the output package's exported
+                       components to specified file; requires --import-path
    --outviews=<file>   Write views output to specified file instead of stdout
    --package=<name>    Package name to use in output (default: "views")
+   --package-per-dir   Generate a Go package for each directory of input
+                       files, in the same directory structure under the
+                       directory of --outviews (each package's views are
+                       written to a file with the name of --outviews) or
+                       under --outdir. Requires --import-path, and
+                       --outviews or --outdir
    --root=<dir>        Root directory for absolute paths in <include />
                        elements (default: ".")
+   --target=<name>,... Generate only the named targets of the config file
+   --watch             Keep running, and generate output again whenever an
+                       input file, or a file included or linked by one,
+                       changes; input directories are watched for new files.
+                       Errors are printed without exiting
+   --watch-interval=<duration>
+                       How often to check files for changes with --watch
+                       (default: "500ms")
+
+Serve flags:
+   The serve command serves a directory over HTTP, generates output whenever
+   files change (like --watch), and reloads pages in the browser. If only
+   the CSS output changes, only the stylesheets of pages are reloaded.
+   Output must be written to files with --outviews or --outdir.
+
+   --addr=<addr>       Address to listen on (default: "localhost:8080")
+   --build=<package>   Build the package for js/wasm before reloading pages,
+                       when views or Go files in the package directory change
+   --dir=<dir>         Directory to serve (default: "."). HTML pages have a
+                       live reload script injected. If the directory has no
+                       wasm_exec.js, the Go installation's is served
+   --wasm=<file>       Output file for --build (default: "<dir>/main.wasm")
+
+Patterns:
+   Patterns for --include and --exclude match paths relative to the input
+   directory, with "/" separators. Each path element is matched as by Go's
+   path.Match ("*", "?", and "[...]"), and "**" matches any number of path
+   elements. A pattern without "/" matches a file or directory name at any
+   depth; a leading "/" matches from the input directory only. Examples:
+   "*.stories.html", "fixtures", "emails/**/*.html".
+
+Config file:
+   A config file is a JSON object with a "targets" list. Each target is an
+   object whose keys are the names of the flags above, without the
+   leading "--", along with "name" and "inputs" (input files and
+   directories). Lists, such as "css-order", are JSON arrays. Relative
+   paths are relative to the config file's directory. Only --check,
+   --watch, --watch-interval, and the serve flags can be used with a
+   config file. For example:
+
+   {
+     "targets": [
+       {
+         "name": "app",
+         "inputs": ["components"],
+         "exclude": ["drafts"],
+         "package": "ui",
+         "outviews": "ui/views.go",
+         "outcss": "public/components.css"
+       }
+     ]
+   }
 
 Example:
    # Recursively find all *.html files in the "components" directory and use
@@ -48,11 +184,40 @@ Example:
 `
 
 var (
-	fHelp        bool
-	fOutViews    string
-	fOutCSS      string
-	fPackageName string
-	fRoot        string
+	fHelp          bool
+	fOutViews      string
+	fOutCSS        string
+	fPackageName   string
+	fRoot          string
+	fEmbedCSS      bool
+	fMinifyCSS     bool
+	fOutCSSMap     string
+	fCSSOrder      string
+	fBackend       string
+	fHydrate       bool
+	fClone         bool
+	fCompact       bool
+	fImportPath    string
+	fOutManifest   string
+	fManifests     string
+	fPkgPerDir     bool
+	fOutDir        string
+	fNaming        string
+	fExportNames   bool
+	fCheck         bool
+	fDepfile       string
+	fWatch         bool
+	fWatchInterval time.Duration
+	fConfig        string
+	fTargets       string
+	fInclude       string
+	fExclude       string
+
+	// serve command
+	fAddr  string
+	fDir   string
+	fBuild string
+	fWasm  string
 )
 
 func printUsage() {
@@ -68,9 +233,42 @@ func main() {
 	flag.StringVar(&fOutCSS, "outcss", "", "")
 	flag.StringVar(&fPackageName, "package", "views", "")
 	flag.StringVar(&fRoot, "root", ".", "")
+	flag.BoolVar(&fEmbedCSS, "embed-css", false, "")
+	flag.BoolVar(&fMinifyCSS, "minify-css", false, "")
+	flag.StringVar(&fOutCSSMap, "outcss-map", "", "")
+	flag.StringVar(&fCSSOrder, "css-order", "", "")
+	flag.StringVar(&fBackend, "backend", "webapi", "")
+	flag.BoolVar(&fHydrate, "hydrate", false, "")
+	flag.BoolVar(&fClone, "clone-templates", false, "")
+	flag.BoolVar(&fCompact, "compact", false, "")
+	flag.StringVar(&fImportPath, "import-path", "", "")
+	flag.StringVar(&fOutManifest, "outmanifest", "", "")
+	flag.StringVar(&fManifests, "manifests", "", "")
+	flag.BoolVar(&fPkgPerDir, "package-per-dir", false, "")
+	flag.StringVar(&fOutDir, "outdir", "", "")
+	flag.StringVar(&fNaming, "naming", "base", "")
+	flag.BoolVar(&fExportNames, "export-names", false, "")
+	flag.BoolVar(&fCheck, "check", false, "")
+	flag.StringVar(&fDepfile, "depfile", "", "")
+	flag.BoolVar(&fWatch, "watch", false, "")
+	flag.DurationVar(&fWatchInterval, "watch-interval", 500*time.Millisecond, "")
+	flag.StringVar(&fConfig, "config", "", "")
+	flag.StringVar(&fTargets, "target", "", "")
+	flag.StringVar(&fInclude, "include", "", "")
+	flag.StringVar(&fExclude, "exclude", "", "")
+
+	serveCmd := len(os.Args) > 1 && os.Args[1] == "serve"
+	flagArgs := os.Args[1:]
+	if serveCmd {
+		flag.StringVar(&fAddr, "addr", "localhost:8080", "")
+		flag.StringVar(&fDir, "dir", ".", "")
+		flag.StringVar(&fBuild, "build", "", "")
+		flag.StringVar(&fWasm, "wasm", "", "")
+		flagArgs = os.Args[2:]
+	}
 
 	flag.Usage = printUsage
-	flag.Parse()
+	flag.CommandLine.Parse(flagArgs)
 
 	if fHelp {
 		printUsage()
@@ -79,102 +277,237 @@ func main() {
 
 	args := flag.Args()
 
-	if len(args) == 0 {
-		printUsage()
+	if len(args) == 0 && fConfig == "" {
+		if _, err := os.Stat(defaultConfig); err != nil {
+			printUsage()
+			os.Exit(2)
+		}
+		fConfig = defaultConfig
+	}
+
+	if serveCmd {
+		if fCheck || fWatch {
+			stderr.Printf("--check and --watch cannot be used with serve")
+			os.Exit(2)
+		}
+		if fWasm != "" && fBuild == "" {
+			stderr.Printf("--wasm requires --build")
+			os.Exit(2)
+		}
+		if fWasm == "" {
+			fWasm = filepath.Join(fDir, "main.wasm")
+		}
+	}
+	if fCheck && fWatch {
+		stderr.Printf("--check cannot be used with --watch")
+		os.Exit(2)
+	}
+	if fWatchInterval <= 0 {
+		stderr.Printf("--watch-interval must be positive")
+		os.Exit(2)
+	}
+
+	targets, err := loadTargets(args)
+	if err != nil {
+		stderr.Printf("%s", err)
 		os.Exit(2)
 	}
+	for _, t := range targets {
+		if err := t.validate(serveCmd); err != nil {
+			if t.Name != "" {
+				err = fmt.Errorf("%s: target %s: %s", fConfig, t.Name, err)
+			}
+			stderr.Printf("%s", err)
+			os.Exit(2)
+		}
+	}
 
-	if err := run(args); err != nil {
+	if serveCmd {
+		err := serve(targets, fWatchInterval)
+		stderr.Printf("%s", err)
+		os.Exit(1)
+	}
+	if fWatch {
+		watch(targets, fWatchInterval)
+	}
+	if _, err := runTargets(targets); err != nil {
 		stderr.Printf("%s", err)
 		os.Exit(1)
 	}
 }
 
-func run(args []string) error {
-	outViews := os.Stdout
-	outCSS := os.Stdout
+// loadTargets returns the targets in the config file, or the target
+// described by the flags, with inputs args, if there is no config file.
+func loadTargets(args []string) ([]*target, error) {
+	if fConfig == "" {
+		if fTargets != "" {
+			return nil, errors.New("--target requires a config file")
+		}
+		return []*target{targetFromFlags(args)}, nil
+	}
 
-	if fOutViews != "" {
-		outViews = createFile(fOutViews)
-		defer outViews.Close()
+	if len(args) != 0 {
+		return nil, errors.New("input files and directories cannot be given with a config file")
 	}
-	if fOutCSS != "" {
-		outCSS = createFile(fOutCSS)
-		defer outCSS.Close()
+	var err error
+	flag.Visit(func(f *flag.Flag) {
+		if !configFlags[f.Name] && err == nil {
+			err = fmt.Errorf("--%s cannot be used with a config file (hint: set %q in the config file)", f.Name, f.Name)
+		}
+	})
+	if err != nil {
+		return nil, err
 	}
+	return readConfig(fConfig, splitNames(fTargets))
+}
 
-	opts := webgen.Options{
-		Package: fPackageName,
-		Root:    fRoot,
-	}
+var backends = map[string]webgen.Backend{
+	"webapi":    webgen.BackendWebAPI,
+	"syscalljs": webgen.BackendSyscallJS,
+	"tinygo":    webgen.BackendTinyGo,
+	"ssr":       webgen.BackendSSR,
+}
 
-	var inFiles []string
-	dedup := make(map[string]struct{})
-	maybeAdd := func(p string) {
-		if _, ok := dedup[p]; ok {
-			return // already present
-		}
-		dedup[p] = struct{}{}
-		inFiles = append(inFiles, p)
+var namingStrategies = map[string]webgen.NamingStrategy{
+	"base":       webgen.NamingBaseName,
+	"dir-prefix": webgen.NamingDirPrefix,
+}
+
+// outputFile is an output of webgen.
+type outputFile struct {
+	kind   string // such as "views"
+	path   string // empty for stdout
+	data   []byte
+	remove bool // whether the file is no longer generated and is removed
+}
+
+// write writes the output, and reports whether the file changed. An
+// existing file with the same contents is left untouched, so that its
+// modification time is kept. Otherwise the file is replaced atomically,
+// so that it is never left partly written.
+func (f outputFile) write() (changed bool, err error) {
+	if f.remove {
+		return true, os.Remove(f.path)
+	}
+	if f.path == "" {
+		_, err := os.Stdout.Write(f.data)
+		return true, err
+	}
+	if existing, err := ioutil.ReadFile(f.path); err == nil && bytes.Equal(existing, f.data) {
+		return false, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(f.path), permDir); err != nil {
+		return false, err
 	}
+	return true, writeFileAtomic(f.path, f.data)
+}
 
-	for _, a := range args {
-		info, err := os.Stat(a)
-		if err != nil {
-			return err
-		}
-		if info.IsDir() {
-			if err := filepath.Walk(a, func(p string, info os.FileInfo, err error) error {
-				if err != nil {
-					return err
-				}
-				if info.IsDir() {
-					return nil // will be handled by recursive walk
-				}
-				if filepath.Ext(p) != ".html" {
-					return nil
-				}
-				if _, ok := dedup[p]; ok {
-					return nil // already present
-				}
-				inFiles = append(inFiles, p)
-				return nil
-			}); err != nil {
-				return err
-			}
-		} else {
-			// assume it's a file.
-			// we also don't check for a .html extension since this is an
-			// explicitly provided command line argument.
-			maybeAdd(a)
-		}
+// writeFileAtomic writes data to a temporary file in the directory of p,
+// and renames it to p. The permissions of an existing file are kept.
+func writeFileAtomic(p string, data []byte) error {
+	perm := os.FileMode(permFile)
+	if info, err := os.Stat(p); err == nil {
+		perm = info.Mode().Perm()
 	}
 
-	views, css, err := webgen.Generate(inFiles, opts)
+	tmp, err := ioutil.TempFile(filepath.Dir(p), "."+filepath.Base(p)+".tmp*")
 	if err != nil {
 		return err
 	}
+	defer os.Remove(tmp.Name()) // no-op after a successful rename
 
-	if _, err := outViews.Write(views); err != nil {
-		return fmt.Errorf("write output views: %s", err)
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
 	}
-	if _, err := outCSS.Write(css); err != nil {
-		return fmt.Errorf("write output css: %s", err)
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), perm); err != nil {
+		return err
 	}
+	return os.Rename(tmp.Name(), p)
+}
 
-	return nil
+// depfile returns a Make-style depfile, in which the output files depend on
+// deps. As with gcc's -MP flag, each dependency also has an empty rule, so
+// that make does not fail when a dependency is removed.
+func depfile(outputs []outputFile, deps []string) []byte {
+	var b bytes.Buffer
+	for _, f := range outputs {
+		if f.path == "" || f.remove {
+			continue // stdout, or not an output
+		}
+		if b.Len() != 0 {
+			b.WriteString(" ")
+		}
+		b.WriteString(depfileEscape(f.path))
+	}
+	b.WriteString(":")
+	for _, d := range deps {
+		b.WriteString(" \\\n  ")
+		b.WriteString(depfileEscape(d))
+	}
+	b.WriteString("\n")
+	for _, d := range deps {
+		b.WriteString("\n" + depfileEscape(d) + ":\n")
+	}
+	return b.Bytes()
 }
 
-func createFile(p string) *os.File {
-	err := os.MkdirAll(filepath.Dir(p), permDir)
-	if err != nil {
-		stderr.Printf("%s", err)
-		os.Exit(1)
+// depfileEscape escapes the path for a depfile, as gcc does, so that make
+// and ninja read it back: a space, "#", or ":" is preceded by a backslash,
+// as are the backslashes right before it, and "$" is doubled. Other
+// backslashes, such as those in Windows paths, are kept as is.
+func depfileEscape(p string) string {
+	var b strings.Builder
+	backslashes := 0 // number of backslashes right before p[i]
+	for i := 0; i < len(p); i++ {
+		switch p[i] {
+		case '\\':
+			backslashes++
+		case ' ', '#', ':':
+			b.WriteString(strings.Repeat("\\", backslashes+1))
+			backslashes = 0
+		case '$':
+			b.WriteByte('$')
+			backslashes = 0
+		default:
+			backslashes = 0
+		}
+		b.WriteByte(p[i])
 	}
-	f, err := os.OpenFile(p, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, permFile)
-	if err != nil {
-		stderr.Printf("%s", err)
-		os.Exit(1)
+	return b.String()
+}
+
+// check compares the outputs with the existing files, and writes a unified
+// diff of each mismatch, or of each file to remove, to w. It returns an
+// error if any file is out of date.
+func check(w io.Writer, outputs []outputFile) error {
+	var stale int
+	for _, f := range outputs {
+		if f.path == "" {
+			continue // stdout; nothing to compare against
+		}
+		existing, err := ioutil.ReadFile(f.path)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		if err == nil && bytes.Equal(existing, f.data) {
+			continue
+		}
+		stale++
+		oldName, newName := f.path, f.path
+		if err != nil {
+			oldName = "/dev/null"
+		}
+		if f.remove {
+			newName = "/dev/null"
+		}
+		fmt.Fprint(w, unifiedDiff(oldName, newName, existing, f.data))
 	}
-	return f
+	if stale != 0 {
+		return fmt.Errorf("%d output file(s) out of date (hint: run webgen without --check)", stale)
+	}
+	return nil
 }
@@ -0,0 +1,292 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOutputFileWrite(t *testing.T) {
+	dir := t.TempDir()
+	p := filepath.Join(dir, "views", "views.go")
+	past := time.Now().Add(-time.Hour).Truncate(time.Second)
+
+	t.Run("new", func(t *testing.T) {
+		changed, err := outputFile{kind: "views", path: p, data: []byte("a\n")}.write()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !changed {
+			t.Errorf("expected changed")
+		}
+		expectFile(t, p, "a\n", permFile)
+	})
+
+	t.Run("unchanged", func(t *testing.T) {
+		if err := os.Chtimes(p, past, past); err != nil {
+			t.Fatal(err)
+		}
+		changed, err := outputFile{kind: "views", path: p, data: []byte("a\n")}.write()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if changed {
+			t.Errorf("expected unchanged")
+		}
+		expectFile(t, p, "a\n", permFile)
+		expectModTime(t, p, past)
+	})
+
+	t.Run("changed", func(t *testing.T) {
+		if err := os.Chmod(p, 0600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(p, past, past); err != nil {
+			t.Fatal(err)
+		}
+		changed, err := outputFile{kind: "views", path: p, data: []byte("b\n")}.write()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !changed {
+			t.Errorf("expected changed")
+		}
+		expectFile(t, p, "b\n", 0600) // mode kept
+		info, err := os.Stat(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !info.ModTime().After(past) {
+			t.Errorf("expected new modification time, got: %s", info.ModTime())
+		}
+
+		// The temporary file is renamed, not left behind.
+		infos, err := ioutil.ReadDir(filepath.Dir(p))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(infos) != 1 {
+			var names []string
+			for _, info := range infos {
+				names = append(names, info.Name())
+			}
+			t.Errorf("expected only views.go, got: %v", names)
+		}
+	})
+
+	t.Run("remove", func(t *testing.T) {
+		changed, err := outputFile{kind: "views", path: p, remove: true}.write()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !changed {
+			t.Errorf("expected changed")
+		}
+		if _, err := os.Stat(p); !os.IsNotExist(err) {
+			t.Errorf("expected file to be removed, got: %v", err)
+		}
+	})
+}
+
+func TestRunErrorKeepsOutputs(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "components", "Card.html"), "<div>\n")
+	writeFile(t, filepath.Join(dir, "webgen.json"), `{"targets": [{
+		"inputs": ["components"],
+		"outviews": "views.go",
+		"outcss": "views.css"
+	}]}`)
+	views := filepath.Join(dir, "views.go")
+	css := filepath.Join(dir, "views.css")
+	writeFile(t, views, "package views\n")
+	writeFile(t, css, ".card {}\n")
+	past := time.Now().Add(-time.Hour).Truncate(time.Second)
+	for _, p := range []string{views, css} {
+		if err := os.Chmod(p, 0600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(p, past, past); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	targets, err := readConfig(filepath.Join(dir, "webgen.json"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := targets[0].run(); err == nil {
+		t.Fatalf("expected error")
+	}
+
+	expectFile(t, views, "package views\n", 0600)
+	expectFile(t, css, ".card {}\n", 0600)
+	expectModTime(t, views, past)
+	expectModTime(t, css, past)
+}
+
+func expectFile(t *testing.T, p, content string, perm os.FileMode) {
+	t.Helper()
+	b, err := ioutil.ReadFile(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != content {
+		t.Errorf("%s: expected contents: %q, got: %q", p, content, b)
+	}
+	info, err := os.Stat(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != perm {
+		t.Errorf("%s: expected mode: %v, got: %v", p, perm, info.Mode().Perm())
+	}
+}
+
+func expectModTime(t *testing.T, p string, modTime time.Time) {
+	t.Helper()
+	info, err := os.Stat(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !info.ModTime().Equal(modTime) {
+		t.Errorf("%s: expected modification time: %s, got: %s", p, modTime, info.ModTime())
+	}
+}
+
+func TestDepfileEscape(t *testing.T) {
+	testcases := []struct {
+		in, expect string
+	}{
+		{"components/Card.html", "components/Card.html"},
+		{"my components/Card.html", `my\ components/Card.html`},
+		{"a  b", `a\ \ b`},
+		{"#1.html", `\#1.html`},
+		{"$HOME/Card.html", "$$HOME/Card.html"},
+		{"a:b.html", `a\:b.html`},
+		{`C:\components\Card.html`, `C\:\components\Card.html`},
+		{`a\ b`, `a\\\ b`},
+		{`a\\#b`, `a\\\\\#b`},
+		{`a\$b`, `a\$$b`},
+		{`a\`, `a\`},
+	}
+
+	for _, tt := range testcases {
+		t.Run(tt.in, func(t *testing.T) {
+			if got := depfileEscape(tt.in); got != tt.expect {
+				t.Errorf("expected: %s, got: %s", tt.expect, got)
+			}
+		})
+	}
+}
+
+func TestDepfile(t *testing.T) {
+	outputs := []outputFile{
+		{kind: "views", path: "views/Card_webgen.go"},
+		{kind: "views", path: "views/Old_webgen.go", remove: true},
+		{kind: "views", path: ""}, // stdout
+		{kind: "css", path: "public/my components.css"},
+	}
+	deps := []string{"components/Card.html", "components/card #1.css"}
+
+	expect := `views/Card_webgen.go public/my\ components.css: \
+  components/Card.html \
+  components/card\ \#1.css
+
+components/Card.html:
+
+components/card\ \#1.css:
+`
+	if got := string(depfile(outputs, deps)); got != expect {
+		t.Errorf("expected:\n%s\ngot:\n%s", expect, got)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	dir := t.TempDir()
+	unchanged := filepath.Join(dir, "Unchanged_webgen.go")
+	changed := filepath.Join(dir, "Changed_webgen.go")
+	missing := filepath.Join(dir, "Missing_webgen.go")
+	removed := filepath.Join(dir, "Removed_webgen.go")
+	newline := filepath.Join(dir, "views.css")
+	writeFile(t, unchanged, "a\n")
+	writeFile(t, changed, "a\nb\n")
+	writeFile(t, removed, "a\n")
+	writeFile(t, newline, "a")
+
+	testcases := []struct {
+		name    string
+		outputs []outputFile
+		expect  string
+		err     string
+	}{
+		{
+			name: "up to date",
+			outputs: []outputFile{
+				{kind: "views", path: unchanged, data: []byte("a\n")},
+				{kind: "views", path: "", data: []byte("stdout\n")},
+			},
+		},
+		{
+			name:    "changed",
+			outputs: []outputFile{{kind: "views", path: changed, data: []byte("a\nc\n")}},
+			expect:  "--- " + changed + "\n+++ " + changed + "\n@@ -1,2 +1,2 @@\n a\n-b\n+c\n",
+			err:     "1 output file(s) out of date (hint: run webgen without --check)",
+		},
+		{
+			name:    "missing",
+			outputs: []outputFile{{kind: "views", path: missing, data: []byte("a\n")}},
+			expect:  "--- /dev/null\n+++ " + missing + "\n@@ -0,0 +1 @@\n+a\n",
+			err:     "1 output file(s) out of date (hint: run webgen without --check)",
+		},
+		{
+			name:    "removed",
+			outputs: []outputFile{{kind: "views", path: removed, remove: true}},
+			expect:  "--- " + removed + "\n+++ /dev/null\n@@ -1 +0,0 @@\n-a\n",
+			err:     "1 output file(s) out of date (hint: run webgen without --check)",
+		},
+		{
+			name:    "newline",
+			outputs: []outputFile{{kind: "css", path: newline, data: []byte("a\n")}},
+			expect:  "--- " + newline + "\n+++ " + newline + "\n@@ -1 +1 @@\n-a\n\\ No newline at end of file\n+a\n",
+			err:     "1 output file(s) out of date (hint: run webgen without --check)",
+		},
+		{
+			name: "several",
+			outputs: []outputFile{
+				{kind: "views", path: unchanged, data: []byte("a\n")},
+				{kind: "views", path: missing, data: []byte("a\n")},
+				{kind: "views", path: removed, remove: true},
+			},
+			expect: "--- /dev/null\n+++ " + missing + "\n@@ -0,0 +1 @@\n+a\n" +
+				"--- " + removed + "\n+++ /dev/null\n@@ -1 +0,0 @@\n-a\n",
+			err: "2 output file(s) out of date (hint: run webgen without --check)",
+		},
+	}
+
+	for _, tt := range testcases {
+		t.Run(tt.name, func(t *testing.T) {
+			var b strings.Builder
+			err := check(&b, tt.outputs)
+			if got := b.String(); got != tt.expect {
+				t.Errorf("expected:\n%s\ngot:\n%s", tt.expect, got)
+			}
+			if tt.err == "" && err != nil {
+				t.Errorf("unexpected error: %s", err)
+			}
+			if tt.err != "" && (err == nil || err.Error() != tt.err) {
+				t.Errorf("expected error: %s, got: %v", tt.err, err)
+			}
+		})
+	}
+
+	// Checking does not write or remove files.
+	if _, err := os.Stat(missing); !os.IsNotExist(err) {
+		t.Errorf("expected %s not to exist, got: %v", missing, err)
+	}
+	if _, err := os.Stat(removed); err != nil {
+		t.Errorf("expected %s to exist, got: %v", removed, err)
+	}
+}
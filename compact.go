@@ -0,0 +1,109 @@
+package webgen
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Build operations for Options.CompactConstructors. Each operation is a
+// string, interpreted by the _build function in the views header:
+//
+//	"<tag"       create an element, append it to the current element (if
+//	             any), and make it the current element
+//	">"          make the current element's parent the current element
+//	"=key=value" set an attribute of the current element
+//	"\"text"     append a text node to the current element
+//	"+"          append the roots of the next included component to the
+//	             current element
+const (
+	opStart   = '<'
+	opEnd     = '>'
+	opAttr    = '='
+	opText    = '"'
+	opInclude = '+'
+)
+
+// writeCompactConstructor writes the build operations variable and the
+// constructor that interprets them, for Options.CompactConstructors.
+func writeCompactConstructor(w io.Writer, d dialect, c *component, injectCSS bool) {
+	e := compactEmitter{
+		namer: newVarNames(),
+		vars:  make(map[*node]string),
+	}
+	for _, n := range c.roots {
+		e.node(n)
+	}
+	// Trailing end operations have no effect.
+	for len(e.ops) != 0 && e.ops[len(e.ops)-1] == string(opEnd) {
+		e.ops = e.ops[:len(e.ops)-1]
+	}
+
+	opsName := "_" + c.typeName + "Ops"
+	fmt.Fprintf(w, "var %s = []string{\n", opsName)
+	for _, op := range e.ops {
+		fmt.Fprintf(w, "%s,\n", strconv.Quote(op))
+	}
+	fmt.Fprint(w, "}\n\n")
+
+	fmt.Fprintf(w, "func %s() *%s {\n", constructorFuncName(c.typeName), c.typeName)
+	var roots []string
+	for _, n := range e.includes {
+		fmt.Fprintf(w, "%s := %s\n", e.vars[n], includeConstructorCall(n))
+		roots = append(roots, includeRoots(n, e.vars[n]))
+	}
+	fmt.Fprintf(w, "e := _build(%s)\n", strings.Join(append([]string{opsName}, roots...), ", "))
+	if injectCSS {
+		fmt.Fprintf(w, "%s()\n", injectCSSFuncName(c.typeName))
+	}
+	writeReturn(w, d, c.typeName, c.refs, e.vars, rootsLiteral(d, e.roots))
+	fmt.Fprint(w, "\n}")
+}
+
+// compactEmitter computes the build operations for a component. Elements
+// are numbered in the order that they are created by _build, and the
+// expressions for them index the slice returned by _build. Include nodes
+// are visited in the same order as by domEmitter, so that variable names
+// match.
+type compactEmitter struct {
+	ops      []string
+	namer    varNames
+	elems    int              // number of elements created
+	depth    int              // number of open elements
+	vars     map[*node]string // element and include nodes -> expressions
+	roots    []string         // roots expressions
+	includes []*node          // include nodes, in document order
+}
+
+func (e *compactEmitter) node(n *node) {
+	switch n.kind {
+	case elementNode:
+		e.namer.next(n.tag)
+		expr := fmt.Sprintf("e[%d]", e.elems)
+		e.elems++
+		e.vars[n] = expr
+		if e.depth == 0 {
+			e.roots = append(e.roots, expr)
+		}
+		e.ops = append(e.ops, string(opStart)+n.tag)
+		e.depth++
+		for _, a := range n.attrs {
+			e.ops = append(e.ops, string(opAttr)+a.key+string(opAttr)+a.val)
+		}
+		for _, child := range n.children {
+			e.node(child)
+		}
+		e.ops = append(e.ops, string(opEnd))
+		e.depth--
+
+	case textNode:
+		e.namer.next("stringliteral")
+		e.ops = append(e.ops, string(opText)+n.text)
+
+	case includeNode:
+		e.vars[n] = e.namer.next(n.tag)
+		e.includes = append(e.includes, n)
+		e.ops = append(e.ops, string(opInclude))
+	}
+}
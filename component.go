@@ -0,0 +1,318 @@
+package webgen
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/littleroot/webgen/ir"
+)
+
+// component is a parsed component file.
+type component struct {
+	path     string
+	typeName string
+	roots    []*node    // top-level nodes; no text nodes
+	refs     []*node    // nodes with a ref attribute, in document order
+	css      []cssChunk // CSS from top-level <style> and <link> elements
+}
+
+func (c *component) hasView() bool {
+	return len(c.roots) != 0
+}
+
+type nodeKind int
+
+const (
+	elementNode nodeKind = iota
+	textNode
+	includeNode
+)
+
+type node struct {
+	kind     nodeKind
+	tag      string // tag name; "include" for include nodes
+	attrs    []attr // element attributes, excluding ref
+	text     string // text content, formatted; for text nodes
+	ref      string // ref attribute value, if any
+	children []*node
+
+	// For include nodes.
+	includePath     string // path of included component file; empty for other packages
+	includeTypeName string // type name of included component
+	includePackage  string // package name of included component, if in another package
+}
+
+// includeQualifier returns the qualifier for identifiers in the included
+// component's package, such as "ui.", or "" for the same package.
+func (n *node) includeQualifier() string {
+	if n.includePackage == "" {
+		return ""
+	}
+	return n.includePackage + "."
+}
+
+type attr struct {
+	key, val string
+}
+
+// parseComponent parses the component file at path, whose contents are src.
+// Components included by the file are generated before parseComponent
+// returns.
+func (g *generator) parseComponent(src []byte, path string, history *orderedSet) (*component, error) {
+	if history.has(path) {
+		var cycle []string
+		history.forEach(func(v string) {
+			cycle = append(cycle, filepath.Base(v))
+		})
+		cycle = append(cycle, filepath.Base(path))
+		return nil, Error{
+			Path: path,
+			Err:  fmt.Errorf("cycle in include paths (%s)", strings.Join(cycle, " -> ")),
+		}
+	}
+
+	history.add(path)
+	defer history.remove(path)
+
+	ic, err := ir.Parse(path, src)
+	if err != nil {
+		var e ir.Error
+		if !errors.As(err, &e) {
+			return nil, Error{Path: path, Err: err}
+		}
+		return nil, Error{
+			Path: e.Path,
+			Err:  fmt.Errorf("%s: %w", e.Pos, e.Err),
+		}
+	}
+
+	b := componentBuilder{
+		g:       g,
+		src:     src,
+		path:    path,
+		history: history,
+		nodes:   make(map[ir.Node]*node),
+		c: &component{
+			path:     path,
+			typeName: g.typeName(path),
+		},
+	}
+	if err := b.build(ic); err != nil {
+		return nil, err
+	}
+	return b.c, nil
+}
+
+// componentBuilder builds a component from its intermediate
+// representation, resolving included components and linked stylesheets.
+type componentBuilder struct {
+	g       *generator
+	src     []byte
+	path    string
+	history *orderedSet
+
+	c     *component
+	nodes map[ir.Node]*node // built nodes
+}
+
+func (b *componentBuilder) build(ic *ir.Component) error {
+	if ic.Name != "" {
+		if !isValidTypeName(ic.Name) {
+			return Error{
+				Path: b.path,
+				Err:  fmt.Errorf(`invalid type name %q in <meta name="webgen:name">`, ic.Name),
+			}
+		}
+		b.c.typeName = ic.Name
+	}
+	// Claim the type name before including other components, so that
+	// imports for them do not take the name.
+	pkg, _ := b.g.packageOf(b.path)
+	if err := b.g.claimTypeName(pkg, b.path, b.c.typeName); err != nil {
+		return err
+	}
+
+	var err error
+	b.c.roots, err = b.buildNodes(ic.Roots, true)
+	if err != nil {
+		return err
+	}
+	for _, r := range ic.Refs {
+		n := b.nodes[r.Node]
+		n.ref = r.Name
+		b.c.refs = append(b.c.refs, n)
+	}
+
+	for _, s := range ic.Styles {
+		c, err := b.style(s)
+		if err != nil {
+			return err
+		}
+		if len(c.text) != 0 {
+			b.c.css = append(b.c.css, c)
+		}
+	}
+	return nil
+}
+
+// buildNodes builds the nodes, which are the roots of the component if top
+// is set.
+func (b *componentBuilder) buildNodes(ins []ir.Node, top bool) ([]*node, error) {
+	var nodes []*node
+	for _, in := range ins {
+		var n *node
+		switch in := in.(type) {
+		case *ir.Element:
+			n = &node{kind: elementNode, tag: in.Tag}
+			for _, a := range in.Attrs {
+				n.attrs = append(n.attrs, attr{a.Key, a.Val})
+			}
+			children, err := b.buildNodes(in.Children, false)
+			if err != nil {
+				return nil, err
+			}
+			n.children = children
+
+		case *ir.Text:
+			text := formatTextContent([]byte(in.Text))
+			if len(text) == 0 {
+				continue
+			}
+			n = &node{kind: textNode, text: string(text)}
+
+		case *ir.Include:
+			var err error
+			n, err = b.include(in)
+			if err != nil {
+				return nil, err
+			}
+			if top {
+				// TODO: top-level <include> *can* be allowed. We just need
+				// to do a bit more code generation.
+				return nil, Error{
+					Path: b.path,
+					Err:  fmt.Errorf("%s: top-level <include> disallowed (hint: nest in <div> or <span>)", in.Pos),
+				}
+			}
+		}
+		b.nodes[in] = n
+		nodes = append(nodes, n)
+	}
+	return nodes, nil
+}
+
+// include builds the node for an <include> element, generating the
+// included component first if it is included by path.
+func (b *componentBuilder) include(in *ir.Include) (*node, error) {
+	n := &node{kind: includeNode, tag: "include"}
+
+	if in.Component != "" {
+		m, typeName, err := b.g.resolveComponent(in.Component)
+		if err != nil {
+			return nil, Error{
+				Path: b.path,
+				Err:  err,
+			}
+		}
+		if b.g.opts.Hydrate && b.g.dialect() != nil {
+			return nil, Error{
+				Path: b.path,
+				Err:  fmt.Errorf("hydrating components from other packages (%s) is not supported", in.Component),
+			}
+		}
+		pkg, _ := b.g.packageOf(b.path)
+		n.includePackage = b.g.addImport(pkg, m.ImportPath, m.Package)
+		n.includeTypeName = typeName
+		return n, nil
+	}
+
+	includePath := b.g.resolvePath(b.path, in.Path)
+
+	err := b.g.generateOneFile(includePath, b.history, b.path)
+	if err != nil {
+		return nil, err
+	}
+
+	// ... successfully included
+	if b.g.includes == nil {
+		b.g.includes = make(map[string][]string)
+	}
+	b.g.includes[b.path] = append(b.g.includes[b.path], includePath)
+	n.includePath = includePath
+	n.includeTypeName = b.g.typeName(includePath)
+	if err := b.includeFromPackage(n); err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+// includeFromPackage sets up the include node to refer to the component
+// in another generated package, if the included component file is in
+// another directory and Options.PackagePerDir is set.
+func (b *componentBuilder) includeFromPackage(n *node) error {
+	from, _ := b.g.packageOf(b.path)
+	to, _ := b.g.packageOf(n.includePath) // already generated
+	if from == to {
+		return nil
+	}
+	if !isExportedName(n.includeTypeName) {
+		return Error{
+			Path: b.path,
+			Err:  fmt.Errorf("included component %s is in another package, so it must be exported (hint: rename %s)", n.includeTypeName, filepath.Base(n.includePath)),
+		}
+	}
+	if b.g.opts.Hydrate && b.g.dialect() != nil {
+		return Error{
+			Path: b.path,
+			Err:  fmt.Errorf("hydrating components from other packages (%s) is not supported", to.importPath),
+		}
+	}
+	n.includePackage = b.g.addImport(from, to.importPath, to.name)
+	return nil
+}
+
+// style returns the CSS of a top-level <style> element, or of the local
+// file linked by a <link rel="stylesheet"> element.
+func (b *componentBuilder) style(s *ir.Style) (cssChunk, error) {
+	if s.Href == "" {
+		line, col := textPosition(b.src, s.Pos.Offset, 0, 0)
+		return cssChunk{
+			component: b.path,
+			path:      b.path,
+			text:      []byte(s.Text),
+			line:      line,
+			col:       col,
+			media:     s.Media,
+		}, nil
+	}
+
+	cssPath := b.g.resolvePath(b.path, s.Href)
+	src, err := b.g.readFile(cssPath)
+	if err != nil {
+		return cssChunk{}, fmt.Errorf("parsing %s: %w", b.path, err)
+	}
+	text := bytes.TrimSpace(src)
+	line, col := textPosition(src, bytes.Index(src, text), 0, 0)
+
+	return cssChunk{
+		component: b.path,
+		path:      cssPath,
+		text:      text,
+		line:      line,
+		col:       col,
+		media:     s.Media,
+	}, nil
+}
+
+// resolvePath resolves a path attribute value specified in the component
+// file at path. Relative paths are resolved relative to the component
+// file's directory, and absolute paths are rooted at Options.Root.
+func (g *generator) resolvePath(path, val string) string {
+	if filepath.IsAbs(val) {
+		return filepath.Join(g.opts.Root, val)
+	}
+	return filepath.Join(filepath.Dir(path), val)
+}
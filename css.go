@@ -0,0 +1,505 @@
+package webgen
+
+import (
+	"strings"
+)
+
+// This file implements a conservative CSS minifier. It removes comments and
+// unnecessary whitespace, shortens hex colors and zero lengths, and merges
+// rules where doing so cannot change the cascade.
+
+type cssTokenKind int
+
+const (
+	cssWhitespace cssTokenKind = iota // also used for comments
+	cssString                         // quoted string, including quotes
+	cssURL                            // unquoted url(...), verbatim
+	cssDelim                          // one of cssDelims
+	cssOther                          // identifiers, numbers, hashes, etc.
+)
+
+const cssDelims = "{}:;,>+~()!"
+
+type cssToken struct {
+	kind cssTokenKind
+	text string
+	pos  int // byte offset in the input
+}
+
+func (t cssToken) is(delim byte) bool {
+	return t.kind == cssDelim && t.text[0] == delim
+}
+
+func tokenizeCSS(s string) []cssToken {
+	var toks []cssToken
+	appendWhitespace := func() {
+		if len(toks) != 0 && toks[len(toks)-1].kind == cssWhitespace {
+			return
+		}
+		toks = append(toks, cssToken{cssWhitespace, " ", -1})
+	}
+
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case strings.HasPrefix(s[i:], "/*"):
+			end := strings.Index(s[i+2:], "*/")
+			if end == -1 {
+				i = len(s)
+			} else {
+				i += 2 + end + 2
+			}
+			appendWhitespace()
+
+		case isCSSSpace(c):
+			for i < len(s) && isCSSSpace(s[i]) {
+				i++
+			}
+			appendWhitespace()
+
+		case c == '"' || c == '\'':
+			j := i + 1
+			for j < len(s) && s[j] != c {
+				if s[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			if j >= len(s) {
+				j = len(s) - 1 // unterminated; take the rest
+			}
+			toks = append(toks, cssToken{cssString, s[i : j+1], i})
+			i = j + 1
+
+		case strings.IndexByte(cssDelims, c) != -1:
+			toks = append(toks, cssToken{cssDelim, s[i : i+1], i})
+			i++
+
+		default:
+			j := i
+			for j < len(s) && !isCSSSpace(s[j]) && s[j] != '"' && s[j] != '\'' &&
+				strings.IndexByte(cssDelims, s[j]) == -1 && !strings.HasPrefix(s[j:], "/*") {
+				if s[j] == '\\' && j+1 < len(s) {
+					j++
+				}
+				j++
+			}
+			if strings.EqualFold(s[i:j], "url") && j < len(s) && s[j] == '(' {
+				if end, ok := unquotedURLEnd(s, j+1); ok {
+					toks = append(toks, cssToken{cssURL, s[i:end], i})
+					i = end
+					continue
+				}
+			}
+			toks = append(toks, cssToken{cssOther, s[i:j], i})
+			i = j
+		}
+	}
+	return toks
+}
+
+// unquotedURLEnd reports the end offset (exclusive of the closing ')') of an
+// unquoted url(...) whose contents begin at offset start.
+func unquotedURLEnd(s string, start int) (int, bool) {
+	j := start
+	for j < len(s) && isCSSSpace(s[j]) {
+		j++
+	}
+	if j < len(s) && (s[j] == '"' || s[j] == '\'') {
+		return 0, false // quoted; tokenize normally
+	}
+	end := strings.IndexByte(s[j:], ')')
+	if end == -1 {
+		return len(s), true
+	}
+	return j + end + 1, true
+}
+
+func isCSSSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+}
+
+// cssRule is a minified rule.
+type cssRule struct {
+	prelude string // selector or at-rule prelude
+	body    string // block contents; meaningful only if block is true
+	block   bool   // false for at-rule statements such as @import
+	pos     int    // byte offset of the rule in the input
+}
+
+func (r cssRule) String() string {
+	if !r.block {
+		return r.prelude + ";"
+	}
+	return r.prelude + "{" + r.body + "}"
+}
+
+// minifyCSS returns a minified stylesheet equivalent to css.
+func minifyCSS(css []byte) []byte {
+	var b strings.Builder
+	for _, r := range minifyCSSRules(css) {
+		b.WriteString(r.String())
+	}
+	return []byte(b.String())
+}
+
+// minifyCSSRules returns the minified top-level rules of the stylesheet css.
+// Concatenating the rules' String values produces the minified stylesheet.
+func minifyCSSRules(css []byte) []cssRule {
+	p := cssParser{toks: tokenizeCSS(string(css))}
+	return mergeCSSRules(p.parseRules())
+}
+
+type cssParser struct {
+	toks []cssToken
+	i    int
+}
+
+func (p *cssParser) done() bool {
+	return p.i >= len(p.toks)
+}
+
+// parseRules parses rules until the end of input or an unmatched "}", which
+// is consumed.
+func (p *cssParser) parseRules() []cssRule {
+	var rules []cssRule
+	for !p.done() {
+		if p.toks[p.i].is('}') {
+			p.i++
+			break
+		}
+		prelude, end := p.collect()
+		if len(trimCSSWhitespace(prelude)) == 0 {
+			continue // stray ";" or whitespace
+		}
+		rules = append(rules, p.parseRule(prelude, end))
+	}
+	return rules
+}
+
+// collect collects tokens up to the next top-level "{", ";" or "}". The
+// returned end token is the zero value at the end of input. "{" and ";" are
+// consumed; "}" is not.
+func (p *cssParser) collect() (toks []cssToken, end cssToken) {
+	depth := 0
+	for ; !p.done(); p.i++ {
+		t := p.toks[p.i]
+		switch {
+		case t.is('('):
+			depth++
+		case t.is(')'):
+			if depth > 0 {
+				depth--
+			}
+		case depth == 0 && (t.is('{') || t.is(';')):
+			p.i++
+			return toks, t
+		case depth == 0 && t.is('}'):
+			return toks, t
+		}
+		toks = append(toks, t)
+	}
+	return toks, cssToken{}
+}
+
+func (p *cssParser) parseRule(prelude []cssToken, end cssToken) cssRule {
+	prelude = trimCSSWhitespace(prelude)
+	pos := prelude[0].pos
+	atRule := prelude[0].kind == cssOther && strings.HasPrefix(prelude[0].text, "@")
+
+	if !end.is('{') {
+		if atRule {
+			return cssRule{prelude: joinCSSTokens(prelude, cssContextAtPrelude), pos: pos}
+		}
+		// A declaration outside a block is invalid; keep it verbatim-ish.
+		return cssRule{prelude: joinCSSTokens(prelude, cssContextValue), pos: pos}
+	}
+
+	if atRule {
+		r := cssRule{prelude: joinCSSTokens(prelude, cssContextAtPrelude), block: true, pos: pos}
+		if hasRuleBlock(prelude[0].text) {
+			for _, nested := range mergeCSSRules(p.parseRules()) {
+				r.body += nested.String()
+			}
+		} else {
+			r.body = p.parseDeclarations()
+		}
+		return r
+	}
+
+	return cssRule{
+		prelude: joinCSSTokens(prelude, cssContextSelector),
+		body:    p.parseDeclarations(),
+		block:   true,
+		pos:     pos,
+	}
+}
+
+// hasRuleBlock reports whether the at-rule with the given name (including
+// the "@") contains rules, as opposed to declarations.
+func hasRuleBlock(name string) bool {
+	name = strings.ToLower(name)
+	if strings.HasSuffix(name, "keyframes") || strings.HasSuffix(name, "document") {
+		return true
+	}
+	switch name {
+	case "@media", "@supports", "@layer", "@container", "@scope", "@starting-style":
+		return true
+	}
+	return false
+}
+
+// parseDeclarations parses declarations, and any nested rules, until the
+// end of input or an unmatched "}", which is consumed. It returns the
+// minified block contents.
+func (p *cssParser) parseDeclarations() string {
+	var b strings.Builder
+	needSemicolon := false
+	for !p.done() {
+		if p.toks[p.i].is('}') {
+			p.i++
+			break
+		}
+		toks, end := p.collect()
+		toks = trimCSSWhitespace(toks)
+		if len(toks) == 0 {
+			continue
+		}
+		if needSemicolon {
+			b.WriteString(";")
+		}
+		if end.is('{') {
+			// Nested rule; needs no separator.
+			b.WriteString(p.parseRule(toks, end).String())
+			needSemicolon = false
+			continue
+		}
+		b.WriteString(minifyDeclaration(toks))
+		needSemicolon = true
+	}
+	return b.String()
+}
+
+func minifyDeclaration(toks []cssToken) string {
+	colon := -1
+	for i, t := range toks {
+		if t.is(':') {
+			colon = i
+			break
+		}
+	}
+	if colon == -1 {
+		return joinCSSTokens(toks, cssContextValue)
+	}
+
+	prop := joinCSSTokens(toks[:colon], cssContextValue)
+	value := trimCSSWhitespace(toks[colon+1:])
+
+	if strings.HasPrefix(prop, "--") {
+		// Custom property values are arbitrary token streams that may be
+		// substituted anywhere; only trim them.
+		var b strings.Builder
+		for _, t := range value {
+			b.WriteString(t.text)
+		}
+		return prop + ":" + b.String()
+	}
+
+	// A unitless zero in the flex shorthand may be interpreted as a flex
+	// factor rather than as the flex basis.
+	keepUnits := strings.EqualFold(prop, "flex")
+
+	depth := 0
+	shortened := make([]cssToken, len(value))
+	for i, t := range value {
+		switch {
+		case t.is('('):
+			depth++
+		case t.is(')'):
+			depth--
+		case t.kind == cssOther:
+			t.text = shortenHexColor(t.text)
+			if depth == 0 && !keepUnits {
+				t.text = shortenZeroLength(t.text)
+			}
+		}
+		shortened[i] = t
+	}
+
+	return prop + ":" + joinCSSTokens(shortened, cssContextValue)
+}
+
+type cssContext int
+
+const (
+	cssContextSelector cssContext = iota
+	cssContextAtPrelude
+	cssContextValue
+)
+
+// joinCSSTokens joins tokens, dropping whitespace that is not significant
+// in the given context.
+func joinCSSTokens(toks []cssToken, ctx cssContext) string {
+	toks = trimCSSWhitespace(toks)
+
+	var b strings.Builder
+	depth := 0
+	for i, t := range toks {
+		switch {
+		case t.is('('):
+			depth++
+		case t.is(')'):
+			depth--
+		}
+		if t.kind != cssWhitespace {
+			b.WriteString(t.text)
+			continue
+		}
+		prev, next := toks[i-1], toks[i+1] // whitespace is never first or last
+		if !dropsSpaceAfter(prev, ctx, depth) && !dropsSpaceBefore(next, ctx, depth) {
+			b.WriteByte(' ')
+		}
+	}
+	return b.String()
+}
+
+func dropsSpaceAfter(t cssToken, ctx cssContext, depth int) bool {
+	if t.kind != cssDelim {
+		return false
+	}
+	switch t.text[0] {
+	case '(', ',':
+		return true
+	case '!':
+		return ctx == cssContextValue
+	case '>', '+', '~':
+		return ctx == cssContextSelector
+	case ':':
+		return ctx == cssContextValue || (ctx == cssContextAtPrelude && depth > 0)
+	}
+	return false
+}
+
+func dropsSpaceBefore(t cssToken, ctx cssContext, depth int) bool {
+	if t.kind != cssDelim {
+		return false
+	}
+	switch t.text[0] {
+	case ')', ',', '!':
+		return true
+	case '>', '+', '~':
+		return ctx == cssContextSelector
+	case ':':
+		return ctx == cssContextValue || (ctx == cssContextAtPrelude && depth > 0)
+	}
+	return false
+}
+
+func trimCSSWhitespace(toks []cssToken) []cssToken {
+	for len(toks) != 0 && toks[0].kind == cssWhitespace {
+		toks = toks[1:]
+	}
+	for len(toks) != 0 && toks[len(toks)-1].kind == cssWhitespace {
+		toks = toks[:len(toks)-1]
+	}
+	return toks
+}
+
+// shortenHexColor shortens a hex color such as "#AABBCC" to "#abc". Other
+// values are returned unchanged.
+func shortenHexColor(s string) string {
+	if len(s) != 7 && len(s) != 9 || s[0] != '#' {
+		return s
+	}
+	h := strings.ToLower(s[1:])
+	for i := 0; i < len(h); i++ {
+		if !('0' <= h[i] && h[i] <= '9' || 'a' <= h[i] && h[i] <= 'f') {
+			return s
+		}
+	}
+	short := "#"
+	for i := 0; i < len(h); i += 2 {
+		if h[i] != h[i+1] {
+			return "#" + h
+		}
+		short += h[i : i+1]
+	}
+	return short
+}
+
+var cssLengthUnits = []string{
+	"px", "em", "rem", "ex", "ch", "vw", "vh", "vmin", "vmax",
+	"cm", "mm", "q", "in", "pt", "pc",
+}
+
+// shortenZeroLength returns "0" for a zero length such as "0px" or "0.0em".
+// Other values, including zero percentages, times, and angles, whose units
+// may be significant, are returned unchanged.
+func shortenZeroLength(s string) string {
+	i := 0
+	for i < len(s) && (s[i] == '0' || s[i] == '.') {
+		i++
+	}
+	if i == 0 || strings.Count(s[:i], ".") > 1 {
+		return s
+	}
+	unit := strings.ToLower(s[i:])
+	for _, u := range cssLengthUnits {
+		if unit == u {
+			return "0"
+		}
+	}
+	return s
+}
+
+// mergeCSSRules merges rules in a list of sibling rules where it is safe to
+// do so, and drops empty rules.
+//
+// A style rule identical to a later style rule is dropped, since the later
+// rule wins the cascade for every declaration. Adjacent style rules with the
+// same selector, or with the same declarations, are combined.
+func mergeCSSRules(rules []cssRule) []cssRule {
+	isStyleRule := func(r cssRule) bool {
+		return r.block && !strings.HasPrefix(r.prelude, "@")
+	}
+
+	last := make(map[string]int)
+	for i, r := range rules {
+		if isStyleRule(r) {
+			last[r.String()] = i
+		}
+	}
+
+	var merged []cssRule
+	for i, r := range rules {
+		if r.block && r.body == "" {
+			continue
+		}
+		if isStyleRule(r) && last[r.String()] != i {
+			continue
+		}
+		if len(merged) != 0 {
+			prev := &merged[len(merged)-1]
+			if isStyleRule(*prev) && isStyleRule(r) {
+				if prev.prelude == r.prelude {
+					prev.body += ";" + r.body
+					continue
+				}
+				if prev.body == r.body && mergeableSelector(prev.prelude) && mergeableSelector(r.prelude) {
+					prev.prelude += "," + r.prelude
+					continue
+				}
+			}
+		}
+		merged = append(merged, r)
+	}
+	return merged
+}
+
+// mergeableSelector reports whether the selector can be combined into a
+// selector list. Browsers drop an entire rule if any selector in its list is
+// unsupported, so vendor-prefixed pseudo-classes and pseudo-elements are not
+// combined.
+func mergeableSelector(sel string) bool {
+	return !strings.Contains(sel, ":-")
+}
@@ -0,0 +1,42 @@
+package webgen
+
+import (
+	"testing"
+)
+
+func TestMinifyCSS(t *testing.T) {
+	testcases := []struct {
+		name, in, expect string
+	}{
+		{"whitespace", ".a  >  .b ,\n.c ~ .d + .e {\n\tcolor : red ;\n}\n", ".a>.b,.c~.d+.e{color:red}"},
+		{"comments", "/* x */ .a/**/.b { /* y */ color: red; }", ".a .b{color:red}"},
+		{"descendant pseudo", ".a :hover { color: red }", ".a :hover{color:red}"},
+		{"strings", `.a::before { content: "  a ,  b  /* c */ "; }`, `.a::before{content:"  a ,  b  /* c */ "}`},
+		{"url", ".a { background: url( a b.png ) no-repeat; }", ".a{background:url( a b.png ) no-repeat}"},
+		{"important", ".a { color: red ! important; }", ".a{color:red!important}"},
+		{"calc", ".a { width: calc( 100% - 0px ); }", ".a{width:calc(100% - 0px)}"},
+		{"hex colors", ".a { color: #AABBCC; background: #aabbcd; border-color: #11223344; }", ".a{color:#abc;background:#aabbcd;border-color:#1234}"},
+		{"hex id selector", "#aabbcc { color: red }", "#aabbcc{color:red}"},
+		{"zero lengths", ".a { margin: 0px 0.0em 10px 0%; transition: opacity 0s; }", ".a{margin:0 0 10px 0%;transition:opacity 0s}"},
+		{"zero flex", ".a { flex: 1 0px; }", ".a{flex:1 0px}"},
+		{"custom property", ".a { --x:  0px  #AABBCC ; }", ".a{--x:0px #AABBCC}"},
+		{"media", "@media screen and (max-width : 100px) { .a { color: red; } .b { color: red; } }", "@media screen and (max-width:100px){.a,.b{color:red}}"},
+		{"page", "@page :first { margin: 0in; }", "@page :first{margin:0}"},
+		{"import", `@import url("a.css") screen;`, `@import url("a.css") screen;`},
+		{"font-face", "@font-face { font-family: X; src: url(x.woff2); }", "@font-face{font-family:X;src:url(x.woff2)}"},
+		{"keyframes", "@keyframes k { 0% { opacity: 0 } 100% { opacity: 1 } }", "@keyframes k{0%{opacity:0}100%{opacity:1}}"},
+		{"empty rule", ".a {} .b { color: red; }", ".b{color:red}"},
+		{"duplicate rule", ".a { color: red } .b { color: blue } .a { color: red }", ".b{color:blue}.a{color:red}"},
+		{"same selector", ".a { color: red } .a { margin: 0 }", ".a{color:red;margin:0}"},
+		{"same declarations", ".a { color: red } .b { color: red } .c { color: blue }", ".a,.b{color:red}.c{color:blue}"},
+		{"vendor selector", ".a::-moz-selection { color: red } .a::selection { color: red }", ".a::-moz-selection{color:red}.a::selection{color:red}"},
+		{"nested rule", ".a { color: red; & .b { color: blue; } margin: 0; }", ".a{color:red;& .b{color:blue}margin:0}"},
+		{"string with brace", `.a { content: "}"; color: red }`, `.a{content:"}";color:red}`},
+	}
+
+	for _, tt := range testcases {
+		t.Run(tt.name, func(t *testing.T) {
+			Equal(t, tt.expect, string(minifyCSS([]byte(tt.in))))
+		})
+	}
+}
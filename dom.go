@@ -0,0 +1,360 @@
+package webgen
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+)
+
+// dialect is the Go API that generated code uses to build the DOM in the
+// browser.
+type dialect interface {
+	// header returns the template for the start of the views output. The
+	// template is executed with viewsHeaderArgs.
+	header() *template.Template
+
+	// elementType returns the Go type of element values, and null returns
+	// an expression for the absence of an element.
+	elementType() string
+	null() string
+
+	// refType returns the Go type of a ref to the element node, and whether
+	// it differs from elementType. If so, refValue converts an element
+	// value to the type.
+	refType(n *node) (typeName string, ok bool)
+	refValue(n *node, v string) string
+
+	// The following methods return Go statements (without trailing
+	// newline) or expressions that operate on element values. textVar
+	// names a variable that the statement may declare.
+	createElement(tag string) string
+	setAttribute(v, key, val string) string
+	setTextContent(v, text, textVar string) string
+	appendText(v, text, textVar string) string
+	appendChild(parent, child string) string
+	firstElementChild(v string) string
+	nextElementSibling(v string) string
+}
+
+// writeDOMComponent writes the Go type, constructor, and Roots method for
+// the component, using the dialect. If css is non-nil, it is embedded, and
+// the constructor injects it. The hydrate functions are written if
+// opts.Hydrate is set, and opts.CloneTemplates and opts.CompactConstructors
+// select the kind of constructor.
+func writeDOMComponent(w io.Writer, d dialect, c *component, css []byte, opts Options) {
+	if !c.hasView() && css == nil {
+		return
+	}
+
+	fmt.Fprintf(w, "// source: %s\n\n", c.path)
+
+	if c.hasView() {
+		writeTypeDefinition(w, d, c.typeName, c.refs)
+		fmt.Fprint(w, "\n\n")
+	}
+
+	if css != nil {
+		writeEmbeddedCSS(w, c.typeName, css)
+		fmt.Fprint(w, "\n\n")
+	}
+
+	if c.hasView() && opts.CloneTemplates {
+		writeTemplateConstructor(w, d, c, css != nil)
+		fmt.Fprint(w, "\n\n")
+	} else if c.hasView() && opts.CompactConstructors {
+		writeCompactConstructor(w, d, c, css != nil)
+		fmt.Fprint(w, "\n\n")
+	} else if c.hasView() {
+		fmt.Fprintf(w, "func %s() *%s {\n", constructorFuncName(c.typeName), c.typeName)
+		e := domEmitter{
+			w:     w,
+			d:     d,
+			namer: newVarNames(),
+			vars:  make(map[*node]string),
+		}
+		e.nodes(c.roots, "")
+		if css != nil {
+			fmt.Fprintf(w, "%s()\n", injectCSSFuncName(c.typeName))
+		}
+		writeReturn(w, d, c.typeName, c.refs, e.vars, rootsLiteral(d, e.roots))
+		fmt.Fprint(w, "\n}\n\n")
+	}
+
+	if c.hasView() {
+		writeRootsMethod(w, d, c.typeName)
+		fmt.Fprint(w, "\n\n")
+
+		if opts.Hydrate {
+			writeHydrateFuncs(w, d, c, css != nil)
+			fmt.Fprint(w, "\n\n")
+		}
+	}
+}
+
+// domEmitter writes the statements of a constructor that create a
+// component's nodes.
+type domEmitter struct {
+	w     io.Writer
+	d     dialect
+	namer varNames
+	vars  map[*node]string // element and include nodes -> var names
+	roots []string         // roots var names
+}
+
+// nodes writes statements that create the nodes and append them to the
+// parent element. parentVar is empty for root nodes.
+func (e *domEmitter) nodes(nodes []*node, parentVar string) {
+	for _, n := range nodes {
+		switch n.kind {
+		case elementNode:
+			varName := e.namer.next(n.tag)
+			e.vars[n] = varName
+			fmt.Fprintf(e.w, "%s := %s\n", varName, e.d.createElement(n.tag))
+			for _, a := range n.attrs {
+				fmt.Fprintf(e.w, "%s\n", e.d.setAttribute(varName, a.key, a.val))
+			}
+			e.nodes(n.children, varName)
+			if parentVar == "" {
+				e.roots = append(e.roots, varName)
+			} else {
+				fmt.Fprintf(e.w, "%s\n", e.d.appendChild(parentVar, varName))
+			}
+
+		case textNode:
+			strName := e.namer.next("stringliteral")
+			if len(nodes) == 1 {
+				fmt.Fprintf(e.w, "%s\n", e.d.setTextContent(parentVar, n.text, strName))
+			} else {
+				// Setting the text content would replace the element's
+				// other children.
+				fmt.Fprintf(e.w, "%s\n", e.d.appendText(parentVar, n.text, strName))
+			}
+
+		case includeNode:
+			varName := e.namer.next(n.tag)
+			e.vars[n] = varName
+			fmt.Fprintf(e.w, "%s := %s\n", varName, includeConstructorCall(n))
+			fmt.Fprintf(e.w, "for _, r := range %s {\n", includeRoots(n, varName))
+			fmt.Fprintf(e.w, "%s\n", e.d.appendChild(parentVar, "r"))
+			fmt.Fprintf(e.w, "}\n")
+		}
+	}
+}
+
+// writeHydrateFuncs writes the exported hydrate function for the component,
+// and the internal one that hydrates the component's roots starting at an
+// element and returns the element after them.
+func writeHydrateFuncs(w io.Writer, d dialect, c *component, injectCSS bool) {
+	fmt.Fprintf(w, "func %s(root %s) (*%s, error) {\n", hydrateFuncName(c.typeName), d.elementType(), c.typeName)
+	fmt.Fprintf(w, "v, _, err := %s(root)\n", internalHydrateFuncName(c.typeName))
+	fmt.Fprintf(w, "return v, err\n")
+	fmt.Fprint(w, "}\n\n")
+
+	fmt.Fprintf(w, "func %s(e %s) (*%s, %s, error) {\n", internalHydrateFuncName(c.typeName), d.elementType(), c.typeName, d.elementType())
+	h := domHydrator{
+		w:        w,
+		d:        d,
+		typeName: c.typeName,
+		namer:    newVarNames(),
+		vars:     make(map[*node]string),
+	}
+	h.nodes(c.roots, "e", true)
+	if injectCSS {
+		fmt.Fprintf(w, "%s()\n", injectCSSFuncName(c.typeName))
+	}
+	fmt.Fprint(w, "v := ")
+	writeComponentLiteral(w, d, c.typeName, c.refs, h.vars, rootsLiteral(d, h.roots))
+	fmt.Fprint(w, "\n")
+	fmt.Fprintf(w, "return v, _e0, nil\n")
+	fmt.Fprint(w, "}")
+}
+
+// domHydrator writes the statements of an internal hydrate function. The
+// element nodes are visited in the same order as by domEmitter, so that
+// variable names match those in the constructor.
+type domHydrator struct {
+	w        io.Writer
+	d        dialect
+	typeName string
+	namer    varNames
+	vars     map[*node]string // element and include nodes -> var names
+	roots    []string         // roots var names
+	cursors  int              // number of cursor variables declared
+	err      bool             // whether err is declared
+}
+
+// nodes writes statements that hydrate the nodes, which are expected to
+// start at the element first. Text nodes are skipped, since their content
+// is not validated. For root nodes, the cursor variable is _e0.
+func (h *domHydrator) nodes(nodes []*node, first string, isRoot bool) {
+	cursor := fmt.Sprintf("_e%d", h.cursors)
+	h.cursors++
+	fmt.Fprintf(h.w, "%s := %s\n", cursor, first)
+
+	for _, n := range nodes {
+		switch n.kind {
+		case elementNode:
+			varName := h.namer.next(n.tag)
+			h.vars[n] = varName
+			marker := ""
+			if isRoot {
+				marker = h.typeName
+			}
+			fmt.Fprintf(h.w, "%s := %s\n", varName, cursor)
+			fmt.Fprintf(h.w, "if err := _hydrateCheck(%s, %q, %q); err != nil {\n", varName, n.tag, marker)
+			fmt.Fprintf(h.w, "return nil, %s, err\n", h.d.null())
+			fmt.Fprintf(h.w, "}\n")
+			if n.ref == "" && hasElementChildren(n) {
+				h.nodes(n.children, h.d.firstElementChild(varName), false)
+			} else {
+				// The children of an element with a ref may be replaced
+				// when rendering, so they are not hydrated. Other names
+				// are still reserved, to match the constructor.
+				h.reserveNames(n.children)
+			}
+			fmt.Fprintf(h.w, "%s = %s\n", cursor, h.d.nextElementSibling(varName))
+			if isRoot {
+				h.roots = append(h.roots, varName)
+			}
+
+		case textNode:
+			h.namer.next("stringliteral")
+
+		case includeNode:
+			varName := h.namer.next(n.tag)
+			h.vars[n] = varName
+			if !h.err {
+				fmt.Fprintf(h.w, "var err error\n")
+				h.err = true
+			}
+			lhs := "_"
+			if n.ref != "" {
+				lhs = varName
+				fmt.Fprintf(h.w, "var %s *%s\n", varName, n.includeQualifier()+n.includeTypeName)
+			}
+			fmt.Fprintf(h.w, "%s, %s, err = %s(%s)\n", lhs, cursor, internalHydrateFuncName(n.includeTypeName), cursor)
+			fmt.Fprintf(h.w, "if err != nil {\n")
+			fmt.Fprintf(h.w, "return nil, %s, err\n", h.d.null())
+			fmt.Fprintf(h.w, "}\n")
+		}
+	}
+
+	if !isRoot {
+		fmt.Fprintf(h.w, "if err := _hydrateEnd(%s); err != nil {\n", cursor)
+		fmt.Fprintf(h.w, "return nil, %s, err\n", h.d.null())
+		fmt.Fprintf(h.w, "}\n")
+	}
+}
+
+// reserveNames advances the namer past the nodes and their descendants.
+func (h *domHydrator) reserveNames(nodes []*node) {
+	for _, n := range nodes {
+		switch n.kind {
+		case elementNode:
+			h.namer.next(n.tag)
+			h.reserveNames(n.children)
+		case textNode:
+			h.namer.next("stringliteral")
+		case includeNode:
+			h.namer.next(n.tag)
+		}
+	}
+}
+
+// includeConstructorCall returns the expression that constructs the
+// component included by the include node.
+func includeConstructorCall(n *node) string {
+	return n.includeQualifier() + constructorFuncName(n.includeTypeName) + "()"
+}
+
+// includeRoots returns the expression for the roots of the included
+// component, whose var name is varName. Components in other packages are
+// accessed through their Roots method.
+func includeRoots(n *node, varName string) string {
+	if n.includePackage != "" {
+		return varName + ".Roots()"
+	}
+	return varName + ".roots"
+}
+
+func hasElementChildren(n *node) bool {
+	for _, c := range n.children {
+		if c.kind != textNode {
+			return true
+		}
+	}
+	return false
+}
+
+func hydrateFuncName(typeName string) string {
+	if isExportedName(typeName) {
+		return "Hydrate" + typeName
+	}
+	return "hydrate" + toUppperFirstRune(typeName)
+}
+
+func internalHydrateFuncName(typeName string) string {
+	return "_hydrate" + toUppperFirstRune(typeName)
+}
+
+func writeReturn(w io.Writer, d dialect, typeName string, refs []*node, vars map[*node]string, roots string) {
+	fmt.Fprint(w, "return ")
+	writeComponentLiteral(w, d, typeName, refs, vars, roots)
+}
+
+// writeComponentLiteral writes a pointer to a component struct literal.
+// roots is the expression for the roots field. Refs without a var name are
+// left nil.
+func writeComponentLiteral(w io.Writer, d dialect, typeName string, refs []*node, vars map[*node]string, roots string) {
+	fmt.Fprintf(w, "&%s{\n", typeName)
+	for _, r := range refs {
+		if vars[r] == "" {
+			continue
+		}
+		if _, ok := d.refType(r); ok {
+			fmt.Fprintf(w, "%s: %s,\n", r.ref, d.refValue(r, vars[r]))
+		} else {
+			fmt.Fprintf(w, "%s: %s,\n", r.ref, vars[r])
+		}
+	}
+	fmt.Fprintf(w, "roots: %s,\n", roots)
+	fmt.Fprint(w, "}")
+}
+
+// rootsLiteral returns a slice literal of the roots var names.
+func rootsLiteral(d dialect, roots []string) string {
+	return fmt.Sprintf("[]%s{%s}", d.elementType(), strings.Join(roots, ", "))
+}
+
+func writeRootsMethod(w io.Writer, d dialect, typeName string) {
+	fmt.Fprintf(w, "func (v *%s) Roots() []%s {\n", typeName, d.elementType())
+	fmt.Fprintf(w, "return v.roots\n")
+	fmt.Fprintf(w, "}")
+}
+
+func writeTypeDefinition(w io.Writer, d dialect, typeName string, refs []*node) {
+	fmt.Fprintf(w, "type %s struct {\n", typeName)
+	for _, r := range refs {
+		typeName := d.elementType()
+		if r.kind == includeNode {
+			typeName = "*" + r.includeQualifier() + r.includeTypeName
+		} else if t, ok := d.refType(r); ok {
+			typeName = t
+		}
+		fmt.Fprintf(w, "%s %s\n", r.ref, typeName)
+	}
+	fmt.Fprintf(w, "roots []%s\n", d.elementType())
+	fmt.Fprint(w, "}")
+}
+
+// writeEmbeddedCSS writes the CSS constant for a component, and a function
+// that injects it into the document at most once.
+func writeEmbeddedCSS(w io.Writer, typeName string, css []byte) {
+	constName := "_" + typeName + "CSS"
+	fmt.Fprintf(w, "const %s = %s\n\n", constName, goStringLiteral(string(css)))
+	fmt.Fprintf(w, "var %sInjected bool\n\n", constName)
+	fmt.Fprintf(w, "func %s() {\n", injectCSSFuncName(typeName))
+	fmt.Fprintf(w, "_injectCSS(&%sInjected, %s)\n", constName, constName)
+	fmt.Fprint(w, "}")
+}
@@ -0,0 +1,123 @@
+package webgen
+
+import (
+	"bytes"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// headerFileName is the name of the file with the shared header, for
+// Options.FilePerComponent. It cannot clash with the name of a component
+// file, which ends in "_webgen.go".
+const headerFileName = "webgen.go"
+
+// File is a generated Go file, for Options.FilePerComponent.
+type File struct {
+	Name  string // file name, such as "Card_webgen.go"
+	Views []byte // generated Go code
+}
+
+// componentFile is the views for a component, for Options.FilePerComponent.
+type componentFile struct {
+	name  string
+	views []byte // excluding the header
+}
+
+// componentFileName returns the name of the Go file for the component with
+// the type name. Type names are unique in a package. A leading "_" is
+// removed, since the go command ignores such files.
+func componentFileName(typeName string) string {
+	return strings.TrimLeft(typeName, "_") + "_webgen.go"
+}
+
+// files returns the header file and component files for the package.
+func (g *generator) files(pkg *viewsPackage) []File {
+	header := g.views(pkg)
+	files := []File{{Name: headerFileName, Views: header}}
+
+	imports := importDecl(header)
+	for _, f := range pkg.files {
+		var b bytes.Buffer
+		b.WriteString("package " + pkg.name + "\n\n")
+		b.WriteString("// Code generated by webgen. DO NOT EDIT.\n\n")
+		b.Write(imports)
+		b.WriteString("\n\n")
+		b.Write(f.views)
+		files = append(files, File{Name: f.name, Views: pruneImports(b.Bytes())})
+	}
+	return files
+}
+
+// importDecl returns the import declaration in the Go source.
+func importDecl(src []byte) []byte {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "", src, parser.ImportsOnly)
+	if err != nil {
+		panic(err) // code bug: we may have generated bad code
+	}
+	if len(f.Decls) == 0 {
+		return nil
+	}
+	d := f.Decls[0]
+	return src[fset.Position(d.Pos()).Offset:fset.Position(d.End()).Offset]
+}
+
+// pruneImports removes unused imports from the Go source, and formats it.
+func pruneImports(src []byte) []byte {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "", src, parser.ParseComments)
+	if err != nil {
+		panic(err) // code bug: we may have generated bad code
+	}
+
+	used := make(map[string]bool)
+	ast.Inspect(f, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if x, ok := sel.X.(*ast.Ident); ok && x.Obj == nil {
+				used[x.Name] = true
+			}
+		}
+		return true
+	})
+
+	var decls []ast.Decl
+	for _, d := range f.Decls {
+		gd, ok := d.(*ast.GenDecl)
+		if !ok || gd.Tok != token.IMPORT {
+			decls = append(decls, d)
+			continue
+		}
+		var specs []ast.Spec
+		for _, s := range gd.Specs {
+			is := s.(*ast.ImportSpec)
+			p, _ := strconv.Unquote(is.Path.Value)
+			name := path.Base(p)
+			if is.Name != nil {
+				name = is.Name.Name
+			}
+			if used[name] {
+				specs = append(specs, s)
+			}
+		}
+		if len(specs) != 0 {
+			gd.Specs = specs
+			decls = append(decls, gd)
+		}
+	}
+	f.Decls = decls
+
+	var b bytes.Buffer
+	if err := format.Node(&b, fset, f); err != nil {
+		panic(err) // code bug: we may have generated bad code
+	}
+	out, err := format.Source(b.Bytes())
+	if err != nil {
+		panic(err) // code bug: we may have generated bad code
+	}
+	return out
+}
@@ -0,0 +1,123 @@
+// Package ir defines the intermediate representation of webgen component
+// files: a model of a parsed component file, from which webgen generates
+// code. Use it to write linters, documentation tools, or alternative
+// backends that understand component files the way webgen does.
+//
+// Parse checks only what can be checked in the file itself. It does not
+// read included component files or linked stylesheets, and does not
+// check that the type name in <meta name="webgen:name"> is usable. It
+// also accepts a top-level <include>, which webgen does not support yet.
+package ir
+
+import "fmt"
+
+// Pos is a position in a file.
+type Pos struct {
+	Offset int // byte offset, starting at 0
+	Line   int // line number, starting at 1
+	Col    int // column number, in bytes, starting at 1
+}
+
+func (p Pos) String() string {
+	return fmt.Sprintf("%d:%d", p.Line, p.Col)
+}
+
+// Component is a parsed component file.
+type Component struct {
+	Path string
+
+	// Name is the type name specified with
+	// <meta name="webgen:name" content="...">, if any. By default, the
+	// type name is derived from the file name.
+	Name string
+
+	Roots  []Node   // top-level elements and includes; top-level text is ignored
+	Refs   []*Ref   // nodes with a ref attribute, in document order
+	Styles []*Style // top-level <style> and <link rel="stylesheet"> elements, in document order
+}
+
+// Node is a node in a component's tree: an *Element, *Text, or *Include.
+type Node interface {
+	Position() Pos
+	node()
+}
+
+// Element is an HTML element.
+type Element struct {
+	Pos      Pos    // position of the start tag
+	Tag      string // tag name
+	Attrs    []Attr // attributes, excluding ref
+	Ref      string // ref attribute value, if any
+	Children []Node
+}
+
+// Attr is an attribute of an element.
+type Attr struct {
+	Key, Val string
+}
+
+// Text is text content. Leading and trailing space and newlines are not
+// significant; webgen removes them.
+type Text struct {
+	Pos  Pos
+	Text string // unescaped text, including space and newlines
+}
+
+// Include is an <include> element, which includes another component. It
+// must not contain elements or text, other than space and newlines.
+type Include struct {
+	Pos Pos
+
+	// Path is the path attribute value: the path of the included component
+	// file, relative to the including file's directory, or to the root
+	// directory if it starts with "/". Empty if Component is set.
+	Path string
+
+	// Component is the component attribute value, in the form
+	// "<import path>.<type name>", for a component of another package.
+	// Empty if Path is set.
+	Component string
+
+	Ref string // ref attribute value, if any
+}
+
+func (n *Element) Position() Pos { return n.Pos }
+func (n *Text) Position() Pos    { return n.Pos }
+func (n *Include) Position() Pos { return n.Pos }
+
+func (*Element) node() {}
+func (*Text) node()    {}
+func (*Include) node() {}
+
+// Ref is a ref attribute, which becomes a field of the component's
+// generated type. The field's type follows from Node: the type of the
+// element for its tag, in the backend's Go API (see GoType), or the
+// included component's type.
+type Ref struct {
+	Name string
+	Node Node // *Element or *Include
+}
+
+// Style is a top-level <style> element, or <link rel="stylesheet">
+// element, which links the CSS in a local file.
+type Style struct {
+	Pos   Pos    // position of Text, or of the <link> start tag
+	Text  string // CSS, trimmed; empty for <link>
+	Href  string // href attribute value of <link>; empty for <style>
+	Media string // media attribute value, if any
+}
+
+// Error is an error in a component file.
+type Error struct {
+	Path string
+	Pos  Pos
+	Err  error
+}
+
+func (e Error) Error() string {
+	return fmt.Sprintf("%s:%s: %s", e.Path, e.Pos, e.Err)
+}
+
+func (e Error) Unwrap() error {
+	return e.Err
+}
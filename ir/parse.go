@@ -0,0 +1,421 @@
+package ir
+
+import (
+	"bytes"
+	"fmt"
+	"go/token"
+	"io"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Parse parses the component file at path, whose contents are src. The
+// error, if any, will be of type Error.
+func Parse(path string, src []byte) (*Component, error) {
+	p := parser{
+		path: path,
+		src:  src,
+		line: 1,
+		col:  1,
+		refs: make(map[string]Node),
+		c:    &Component{Path: path},
+	}
+	return p.parse()
+}
+
+type parser struct {
+	path string
+	src  []byte
+	z    *html.Tokenizer
+
+	// Position of the current token's start, and the offset of its end.
+	pos Pos
+	end int
+
+	// Position last computed by posAt.
+	offset, line, col int
+
+	c    *Component
+	open []Node          // currently open elements and includes
+	refs map[string]Node // ref attribute value -> node
+}
+
+func (p *parser) errorf(pos Pos, format string, args ...interface{}) error {
+	return Error{
+		Path: p.path,
+		Pos:  pos,
+		Err:  fmt.Errorf(format, args...),
+	}
+}
+
+// posAt returns the position of the offset in src, which must not be less
+// than the offset of the previous call.
+func (p *parser) posAt(offset int) Pos {
+	for _, b := range p.src[p.offset:offset] {
+		if b == '\n' {
+			p.line++
+			p.col = 1
+		} else {
+			p.col++
+		}
+	}
+	p.offset = offset
+	return Pos{Offset: offset, Line: p.line, Col: p.col}
+}
+
+func (p *parser) parse() (*Component, error) {
+	p.z = html.NewTokenizer(bytes.NewReader(p.src))
+
+	var style *Style     // non-nil inside a top-level <style>
+	var skipLinkEnd bool // whether to skip a top-level </link>
+	var skipMetaEnd bool // whether to skip a top-level </meta>
+
+	for {
+		tt := p.z.Next()
+		raw := p.z.Raw()
+		p.pos = p.posAt(p.end)
+		p.end += len(raw)
+
+		switch tt {
+		case html.ErrorToken:
+			if p.z.Err() == io.EOF {
+				if style != nil {
+					p.c.Styles = append(p.c.Styles, style) // unterminated <style>
+				}
+				if len(p.open) != 0 {
+					var tags []string
+					for _, n := range p.open {
+						tags = append(tags, tagName(n))
+					}
+					return nil, p.errorf(p.pos, "unclosed elements: %s", strings.Join(tags, ", "))
+				}
+				return p.c, nil
+			}
+			return nil, p.errorf(p.pos, "tokenize HTML: %w", p.z.Err())
+
+		case html.TextToken:
+			if style != nil {
+				text := p.z.Text()
+				trimmed := bytes.TrimSpace(text)
+				style.Text = string(trimmed)
+				style.Pos = p.posAt(p.pos.Offset + bytes.Index(text, trimmed))
+				continue
+			}
+			if len(p.open) == 0 {
+				// text node without parent
+				// TODO: log a warning?
+				continue
+			}
+			if _, ok := p.open[len(p.open)-1].(*Include); ok {
+				text := p.z.Text()
+				trimmed := bytes.TrimSpace(text)
+				if len(trimmed) == 0 {
+					continue // formatting
+				}
+				pos := p.posAt(p.pos.Offset + bytes.Index(text, trimmed))
+				return nil, p.errorf(pos, "<include> must not contain text")
+			}
+			p.add(&Text{Pos: p.pos, Text: string(p.z.Text())})
+
+		case html.StartTagToken:
+			tn, hasAttr := p.z.TagName()
+			tagName := string(tn)
+
+			if len(p.open) == 0 {
+				switch tagName {
+				case "style":
+					style = &Style{Pos: p.pos, Media: p.styleMedia(hasAttr)}
+					continue
+				case "link":
+					if err := p.link(hasAttr); err != nil {
+						return nil, err
+					}
+					skipLinkEnd = true
+					continue
+				case "meta":
+					if err := p.meta(hasAttr); err != nil {
+						return nil, err
+					}
+					skipMetaEnd = true
+					continue
+				}
+			}
+
+			n, err := p.start(tagName, hasAttr)
+			if err != nil {
+				return nil, err
+			}
+			p.open = append(p.open, n)
+
+		case html.EndTagToken:
+			if style != nil {
+				// The tokenizer ends the <style> element's text only at
+				// </style>.
+				p.c.Styles = append(p.c.Styles, style)
+				style = nil
+				continue
+			}
+			tn, _ := p.z.TagName()
+			tag := string(tn)
+			if len(p.open) == 0 {
+				if tag == "link" && skipLinkEnd {
+					skipLinkEnd = false
+					continue
+				}
+				if tag == "meta" && skipMetaEnd {
+					skipMetaEnd = false
+					continue
+				}
+				return nil, p.errorf(p.pos, "unexpected end tag </%s>", tag)
+			}
+			if open := tagName(p.open[len(p.open)-1]); tag != open {
+				return nil, p.errorf(p.pos, "unexpected end tag </%s> (hint: close <%s> first)", tag, open)
+			}
+			p.open = p.open[:len(p.open)-1]
+
+		case html.SelfClosingTagToken:
+			tn, hasAttr := p.z.TagName()
+			tagName := string(tn)
+
+			if len(p.open) == 0 {
+				switch tagName {
+				case "style":
+					continue // empty
+				case "link":
+					if err := p.link(hasAttr); err != nil {
+						return nil, err
+					}
+					continue
+				case "meta":
+					if err := p.meta(hasAttr); err != nil {
+						return nil, err
+					}
+					continue
+				}
+			}
+
+			if _, err := p.start(tagName, hasAttr); err != nil {
+				return nil, err
+			}
+
+		case html.CommentToken, html.DoctypeToken:
+			// ignore
+		}
+	}
+}
+
+// add adds n as a child of the innermost open element, which must not be
+// an include, or as a root.
+func (p *parser) add(n Node) {
+	if len(p.open) == 0 {
+		p.c.Roots = append(p.c.Roots, n)
+		return
+	}
+	parent := p.open[len(p.open)-1].(*Element)
+	parent.Children = append(parent.Children, n)
+}
+
+// addRef records the ref attribute of n.
+func (p *parser) addRef(n Node, ref string) error {
+	if disallowed, reason := isDisallowedRefName(ref); disallowed {
+		return p.errorf(p.pos, "ref name %q disallowed (%s)", ref, reason)
+	}
+	if ex, ok := p.refs[ref]; ok {
+		return p.errorf(p.pos, "ref name %q present multiple times (previous occurence in <%s>)", ref, tagName(ex))
+	}
+	p.refs[ref] = n
+	p.c.Refs = append(p.c.Refs, &Ref{Name: ref, Node: n})
+	return nil
+}
+
+// start handles a start tag or self-closing tag, and adds the new node to
+// the component.
+func (p *parser) start(tagName string, hasAttr bool) (Node, error) {
+	if len(p.open) != 0 {
+		if _, ok := p.open[len(p.open)-1].(*Include); ok {
+			return nil, p.errorf(p.pos, "<include> must not contain elements")
+		}
+	}
+
+	var n Node
+	var err error
+	if tagName == "include" {
+		n, err = p.startInclude(hasAttr)
+	} else {
+		n, err = p.startElement(tagName, hasAttr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	p.add(n)
+	return n, nil
+}
+
+func (p *parser) startElement(tagName string, hasAttr bool) (*Element, error) {
+	n := &Element{Pos: p.pos, Tag: tagName}
+	err := p.attrs(hasAttr, func(k, v string) error {
+		if k == "ref" {
+			n.Ref = v
+			return p.addRef(n, v)
+		}
+		n.Attrs = append(n.Attrs, Attr{k, v})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+func (p *parser) startInclude(hasAttr bool) (*Include, error) {
+	n := &Include{Pos: p.pos}
+
+	var foundPathAttr, foundComponentAttr bool
+
+	err := p.attrs(hasAttr, func(k, v string) error {
+		switch k {
+		case "ref":
+			n.Ref = v
+		case "path":
+			foundPathAttr = true
+			n.Path = v
+		case "component":
+			foundComponentAttr = true
+			n.Component = v
+		default:
+			return p.errorf(p.pos, "<include> specifies invalid attribute %q", k)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if !foundPathAttr && !foundComponentAttr {
+		return nil, p.errorf(p.pos, `missing required "path" or "component" attribute in <include>`)
+	}
+	if foundPathAttr && foundComponentAttr {
+		return nil, p.errorf(p.pos, `<include> must not specify both "path" and "component" attributes`)
+	}
+	if n.Ref != "" {
+		if err := p.addRef(n, n.Ref); err != nil {
+			return nil, err
+		}
+	}
+	return n, nil
+}
+
+// styleMedia returns the value of the media attribute of a <style> element.
+func (p *parser) styleMedia(hasAttr bool) string {
+	var media string
+	p.attrs(hasAttr, func(k, v string) error {
+		if k == "media" {
+			media = v
+		}
+		return nil
+	})
+	return media
+}
+
+// meta handles a top-level <meta> element, which specifies metadata for
+// the component. The only supported metadata is the component's type
+// name: <meta name="webgen:name" content="UserCard">.
+func (p *parser) meta(hasAttr bool) error {
+	var name, content string
+	p.attrs(hasAttr, func(k, v string) error {
+		switch k {
+		case "name":
+			name = v
+		case "content":
+			content = v
+		}
+		return nil
+	})
+
+	if name != "webgen:name" {
+		return p.errorf(p.pos, `top-level <meta> must have name="webgen:name"`)
+	}
+	if content == "" {
+		return p.errorf(p.pos, `missing required "content" attribute in <meta name="webgen:name">`)
+	}
+	if p.c.Name != "" {
+		return p.errorf(p.pos, `<meta name="webgen:name"> present multiple times`)
+	}
+	p.c.Name = content
+	return nil
+}
+
+// link handles a top-level <link rel="stylesheet"> element, which links
+// the CSS in a local file.
+func (p *parser) link(hasAttr bool) error {
+	s := &Style{Pos: p.pos}
+	var rel string
+	var foundHref bool
+	p.attrs(hasAttr, func(k, v string) error {
+		switch k {
+		case "rel":
+			rel = v
+		case "href":
+			s.Href = v
+			foundHref = true
+		case "media":
+			s.Media = v
+		}
+		return nil
+	})
+
+	if !hasStylesheetRel(rel) {
+		return p.errorf(p.pos, `top-level <link> must have rel="stylesheet"`)
+	}
+	if !foundHref {
+		return p.errorf(p.pos, `missing required "href" attribute in <link>`)
+	}
+	if strings.HasPrefix(s.Href, "//") || strings.Contains(s.Href, "://") {
+		return p.errorf(p.pos, "<link> href %q must be a local file", s.Href)
+	}
+	p.c.Styles = append(p.c.Styles, s)
+	return nil
+}
+
+// attrs calls f for each attribute of the current tag.
+func (p *parser) attrs(hasAttr bool, f func(k, v string) error) error {
+	for hasAttr {
+		var k, v []byte
+		k, v, hasAttr = p.z.TagAttr()
+		if err := f(string(k), string(v)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func hasStylesheetRel(rel string) bool {
+	for _, r := range strings.Fields(rel) {
+		if strings.EqualFold(r, "stylesheet") {
+			return true
+		}
+	}
+	return false
+}
+
+func isDisallowedRefName(name string) (disallowed bool, reason string) {
+	if token.IsKeyword(name) {
+		return true, "Go keyword"
+	}
+	if !token.IsIdentifier(name) {
+		return true, "invalid Go identifier"
+	}
+	if name == "Roots" || name == "roots" {
+		return true, "internal use"
+	}
+	return false, ""
+}
+
+// tagName returns the tag name of the node, for error messages.
+func tagName(n Node) string {
+	if e, ok := n.(*Element); ok {
+		return e.Tag
+	}
+	return "include"
+}
@@ -0,0 +1,136 @@
+package ir
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	src := `<meta name="webgen:name" content="Card">
+<div class="card">
+	<h1 ref="title">Hello &amp; welcome</h1>
+	<include path="/Icon.html" ref="icon" />
+</div>
+<link rel="stylesheet" href="card.css" media="print">
+<style>
+	.card { color: red; }
+</style>
+`
+
+	c, err := Parse("Card.html", []byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h1 := &Element{
+		Pos:      Pos{61, 3, 2},
+		Tag:      "h1",
+		Ref:      "title",
+		Children: []Node{&Text{Pos: Pos{77, 3, 18}, Text: "Hello & welcome"}},
+	}
+	icon := &Include{Pos: Pos{103, 4, 2}, Path: "/Icon.html", Ref: "icon"}
+	expect := &Component{
+		Path: "Card.html",
+		Name: "Card",
+		Roots: []Node{
+			&Element{
+				Pos:   Pos{41, 2, 1},
+				Tag:   "div",
+				Attrs: []Attr{{"class", "card"}},
+				Children: []Node{
+					&Text{Pos: Pos{59, 2, 19}, Text: "\n\t"},
+					h1,
+					&Text{Pos: Pos{101, 3, 42}, Text: "\n\t"},
+					icon,
+					&Text{Pos: Pos{143, 4, 42}, Text: "\n"},
+				},
+			},
+		},
+		Refs: []*Ref{
+			{Name: "title", Node: h1},
+			{Name: "icon", Node: icon},
+		},
+		Styles: []*Style{
+			{Pos: Pos{151, 6, 1}, Href: "card.css", Media: "print"},
+			{Pos: Pos{214, 8, 2}, Text: ".card { color: red; }"},
+		},
+	}
+	if !reflect.DeepEqual(expect, c) {
+		t.Errorf("expected:\n%s\ngot:\n%s", dump(expect), dump(c))
+	}
+}
+
+func TestParseTopLevelInclude(t *testing.T) {
+	c, err := Parse("x.html", []byte(`<include path="a.html" ref="a" />`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	in := &Include{Pos: Pos{0, 1, 1}, Path: "a.html", Ref: "a"}
+	expect := &Component{
+		Path:  "x.html",
+		Roots: []Node{in},
+		Refs:  []*Ref{{Name: "a", Node: in}},
+	}
+	if !reflect.DeepEqual(expect, c) {
+		t.Errorf("expected:\n%s\ngot:\n%s", dump(expect), dump(c))
+	}
+}
+
+func TestParseError(t *testing.T) {
+	testcases := []struct {
+		name, src, err string
+	}{
+		{"unexpectedEndTag", "<div>\n</div></span>", "x.html:2:7: unexpected end tag </span>"},
+		{"unclosed", "<div>\n\t<span>", "x.html:2:8: unclosed elements: div, span"},
+		{"mismatchedEndTag", "<div><span></div></span>", "x.html:1:12: unexpected end tag </div> (hint: close <span> first)"},
+		{"mismatchedIncludeEndTag", "<div><include path=\"a.html\"></div>", "x.html:1:29: unexpected end tag </div> (hint: close <include> first)"},
+		{"metaNameEmpty", `<meta name="webgen:name" content="">`, `x.html:1:1: missing required "content" attribute in <meta name="webgen:name">`},
+		{"metaNameMissing", `<meta name="webgen:name">`, `x.html:1:1: missing required "content" attribute in <meta name="webgen:name">`},
+		{"metaNameRepeated", "<meta name=\"webgen:name\" content=\"A\">\n<meta name=\"webgen:name\" content=\"B\" />", `x.html:2:1: <meta name="webgen:name"> present multiple times`},
+		{"repeatedRef", `<div ref="a"><p ref="a"></p></div>`, `x.html:1:14: ref name "a" present multiple times (previous occurence in <div>)`},
+		{"includeContent", "<div><include path=\"a.html\">\n<p></p></include></div>", "x.html:2:1: <include> must not contain elements"},
+		{"includeText", "<div><include path=\"a.html\">\n\tHi </include></div>", "x.html:2:2: <include> must not contain text"},
+	}
+
+	for _, tt := range testcases {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse("x.html", []byte(tt.src))
+			if err == nil {
+				t.Fatalf("err unexpectedly nil")
+			}
+			if _, ok := err.(Error); !ok {
+				t.Errorf("expected err of type Error, got %T", err)
+			}
+			if err.Error() != tt.err {
+				t.Errorf("expected err: %q, got: %q", tt.err, err.Error())
+			}
+		})
+	}
+}
+
+// dump returns a readable representation of the component, for test
+// failures.
+func dump(c *Component) string {
+	s := fmt.Sprintf("%+v", c)
+	for _, r := range c.Roots {
+		s += "\n" + dumpNode(r, "\t")
+	}
+	for _, r := range c.Refs {
+		s += "\nref " + fmt.Sprintf("%+v", r)
+	}
+	for _, st := range c.Styles {
+		s += "\nstyle " + fmt.Sprintf("%+v", st)
+	}
+	return s
+}
+
+func dumpNode(n Node, indent string) string {
+	s := indent + fmt.Sprintf("%+v", n)
+	if e, ok := n.(*Element); ok {
+		for _, c := range e.Children {
+			s += "\n" + dumpNode(c, indent+"\t")
+		}
+	}
+	return s
+}
@@ -0,0 +1,173 @@
+package ir
+
+import "fmt"
+
+// Backend is a kind of Go code that webgen generates for components. See
+// webgen.Backend, which is the same type, for what each generates.
+type Backend int
+
+const (
+	BackendWebAPI Backend = iota
+	BackendSSR
+	BackendSyscallJS
+	BackendTinyGo
+)
+
+func (b Backend) String() string {
+	switch b {
+	case BackendWebAPI:
+		return "webapi"
+	case BackendSSR:
+		return "ssr"
+	case BackendSyscallJS:
+		return "syscalljs"
+	case BackendTinyGo:
+		return "tinygo"
+	}
+	return fmt.Sprintf("Backend(%d)", int(b))
+}
+
+func (b Backend) valid() bool {
+	return b >= BackendWebAPI && b <= BackendTinyGo
+}
+
+// GoType returns the Go type of the ref's field in the code that webgen
+// generates for the backend, and the import path of the type's package.
+// For example, the type for <div ref="x"> is "*html.HTMLDivElement", of
+// package "github.com/gowebapi/webapi/html", for BackendWebAPI, and
+// "js.Value", of package "syscall/js", for BackendSyscallJS. For
+// BackendSSR, the field is in the component's props struct.
+//
+// GoType returns empty strings for a ref to an <include>, whose type is
+// the included component's type (or props type, for BackendSSR). It
+// panics if the backend is unknown.
+func (r *Ref) GoType(b Backend) (typ, importPath string) {
+	if !b.valid() {
+		panic("unknown backend")
+	}
+	e, ok := r.Node.(*Element)
+	if !ok {
+		return "", ""
+	}
+	if b == BackendSSR {
+		return "func(w io.Writer) error", "io"
+	}
+	typ, importPath, _ = ElementType(b, e.Tag)
+	return typ, importPath
+}
+
+// ElementType returns the Go type of elements with the tag in the code that
+// webgen generates for the backend, the import path of the type's package,
+// and whether the type is specific to the tag, rather than the type of all
+// elements. ElementType returns empty strings for BackendSSR, whose code
+// has no element values. It panics if the backend is unknown.
+func ElementType(b Backend, tag string) (typ, importPath string, specific bool) {
+	switch b {
+	case BackendWebAPI:
+		t, ok := webapiTypes[tag]
+		if !ok {
+			return "*dom.Element", webapiImportPaths["dom"], false
+		}
+		return "*" + t.Package + ".HTML" + t.Type + "Element", webapiImportPaths[t.Package], true
+	case BackendSyscallJS, BackendTinyGo:
+		return "js.Value", "syscall/js", false
+	case BackendSSR:
+		return "", "", false
+	}
+	panic("unknown backend")
+}
+
+// webapiImportPaths maps the names of the github.com/gowebapi/webapi
+// packages in webapiTypes to their import paths.
+var webapiImportPaths = map[string]string{
+	"dom":    "github.com/gowebapi/webapi/dom",
+	"html":   "github.com/gowebapi/webapi/html",
+	"canvas": "github.com/gowebapi/webapi/html/canvas",
+	"media":  "github.com/gowebapi/webapi/html/media",
+}
+
+// webapiTypes maps tag names to the names of their packages and element
+// types in github.com/gowebapi/webapi. Other elements are *dom.Element.
+// Obtained from webapi@v0.0.0-20201112202446-44407bcf554b.
+var webapiTypes = map[string]struct {
+	Package string
+	Type    string
+}{
+	// "github.com/gowebapi/webapi/html/canvas"
+	"canvas": {"canvas", "Canvas"},
+
+	// "github.com/gowebapi/webapi/html/media"
+	"audio": {"media", "Audio"},
+	"media": {"media", "Media"},
+	"track": {"media", "Track"},
+	"video": {"media", "Video"},
+
+	// "github.com/gowebapi/webapi/html"
+	"a":        {"html", "Anchor"},
+	"area":     {"html", "Area"},
+	"br":       {"html", "BR"},
+	"base":     {"html", "Base"},
+	"body":     {"html", "Body"},
+	"button":   {"html", "Button"},
+	"dl":       {"html", "DList"},
+	"data":     {"html", "Data"},
+	"datalist": {"html", "DataList"},
+	"details":  {"html", "Details"},
+	"dialog":   {"html", "Dialog"},
+	"dir":      {"html", "Directory"},
+	"div":      {"html", "Div"},
+	// skip type HTMLElement
+	"fieldset": {"html", "FieldSet"},
+	"font":     {"html", "Font"},
+	"form":     {"html", "Form"},
+	"frameset": {"html", "FrameSet"},
+	"hr":       {"html", "HR"},
+	"head":     {"html", "Head"},
+	"h1":       {"html", "Heading"},
+	"h2":       {"html", "Heading"},
+	"h3":       {"html", "Heading"},
+	"h4":       {"html", "Heading"},
+	"h5":       {"html", "Heading"},
+	"h6":       {"html", "Heading"},
+	"html":     {"html", "Html"},
+	"img":      {"html", "Image"},
+	"input":    {"html", "Input"},
+	"li":       {"html", "LI"},
+	"label":    {"html", "Label"},
+	"legend":   {"html", "Legend"},
+	"link":     {"html", "Link"},
+	"map":      {"html", "Map"},
+	"marquee":  {"html", "Marquee"},
+	"menu":     {"html", "Menu"},
+	"meta":     {"html", "Meta"},
+	"meter":    {"html", "Meter"},
+	"mod":      {"html", "Mod"},
+	"ol":       {"html", "OList"},
+	"optgroup": {"html", "OptGroup"},
+	"option":   {"html", "Option"},
+	"output":   {"html", "Output"},
+	"p":        {"html", "Paragraph"},
+	"param":    {"html", "Param"},
+	"picture":  {"html", "Picture"},
+	"pre":      {"html", "Pre"},
+	"progress": {"html", "Progress"},
+	"quote":    {"html", "Quote"},
+	"script":   {"html", "Script"},
+	"select":   {"html", "Select"},
+	"slot":     {"html", "Slot"},
+	"source":   {"html", "Source"},
+	"span":     {"html", "Span"},
+	"style":    {"html", "Style"},
+	"caption":  {"html", "TableCaption"},
+	"td":       {"html", "TableCell"},
+	"colgroup": {"html", "TableCol"},
+	"table":    {"html", "Table"},
+	"tr":       {"html", "TableRow"},
+	"tbody":    {"html", "TableSection"},
+	"template": {"html", "Template"},
+	"textarea": {"html", "TextArea"},
+	"time":     {"html", "Time"},
+	"title":    {"html", "Title"},
+	"ul":       {"html", "UList"},
+	// skip type HTMLUnknownElement
+}
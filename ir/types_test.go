@@ -0,0 +1,68 @@
+package ir
+
+import "testing"
+
+func TestGoType(t *testing.T) {
+	testcases := []struct {
+		backend Backend
+		node    Node
+		typ     string
+		path    string
+	}{
+		{BackendWebAPI, &Element{Tag: "div"}, "*html.HTMLDivElement", "github.com/gowebapi/webapi/html"},
+		{BackendWebAPI, &Element{Tag: "h3"}, "*html.HTMLHeadingElement", "github.com/gowebapi/webapi/html"},
+		{BackendWebAPI, &Element{Tag: "canvas"}, "*canvas.HTMLCanvasElement", "github.com/gowebapi/webapi/html/canvas"},
+		{BackendWebAPI, &Element{Tag: "video"}, "*media.HTMLVideoElement", "github.com/gowebapi/webapi/html/media"},
+		{BackendWebAPI, &Element{Tag: "section"}, "*dom.Element", "github.com/gowebapi/webapi/dom"},
+		{BackendSyscallJS, &Element{Tag: "div"}, "js.Value", "syscall/js"},
+		{BackendTinyGo, &Element{Tag: "div"}, "js.Value", "syscall/js"},
+		{BackendSSR, &Element{Tag: "div"}, "func(w io.Writer) error", "io"},
+		{BackendWebAPI, &Include{Path: "Icon.html"}, "", ""},
+		{BackendSSR, &Include{Path: "Icon.html"}, "", ""},
+	}
+
+	for _, tt := range testcases {
+		t.Run(tt.backend.String()+" "+tagName(tt.node), func(t *testing.T) {
+			r := &Ref{Name: "x", Node: tt.node}
+			typ, path := r.GoType(tt.backend)
+			if typ != tt.typ || path != tt.path {
+				t.Errorf("expected: %s (%s), got: %s (%s)", tt.typ, tt.path, typ, path)
+			}
+		})
+	}
+
+	t.Run("unknown", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("expected panic")
+			}
+		}()
+		(&Ref{Name: "x", Node: &Include{Path: "Icon.html"}}).GoType(Backend(-1))
+	})
+}
+
+func TestElementType(t *testing.T) {
+	testcases := []struct {
+		backend  Backend
+		tag      string
+		typ      string
+		specific bool
+	}{
+		{BackendWebAPI, "div", "*html.HTMLDivElement", true},
+		{BackendWebAPI, "audio", "*media.HTMLAudioElement", true},
+		{BackendWebAPI, "section", "*dom.Element", false},
+		{BackendWebAPI, "my-element", "*dom.Element", false},
+		{BackendSyscallJS, "div", "js.Value", false},
+		{BackendTinyGo, "canvas", "js.Value", false},
+		{BackendSSR, "div", "", false},
+	}
+
+	for _, tt := range testcases {
+		t.Run(tt.backend.String()+" "+tt.tag, func(t *testing.T) {
+			typ, _, specific := ElementType(tt.backend, tt.tag)
+			if typ != tt.typ || specific != tt.specific {
+				t.Errorf("expected: %s (specific: %v), got: %s (specific: %v)", tt.typ, tt.specific, typ, specific)
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package webgen
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+)
+
+// Manifest describes the components generated into a Go package, so that
+// components in other packages can include them. A manifest is produced
+// when Options.ImportPath is set, and is used by listing it in
+// Options.Manifests.
+type Manifest struct {
+	ImportPath string   `json:"importPath"`
+	Package    string   `json:"package"`
+	Backend    string   `json:"backend"`
+	Components []string `json:"components"` // exported type names, sorted
+
+	path string // path of the manifest file, if read from one
+}
+
+// manifest returns the encoded manifest for the generated package.
+func (g *generator) manifest(pkg *viewsPackage) []byte {
+	m := Manifest{
+		ImportPath: pkg.importPath,
+		Package:    pkg.name,
+		Backend:    g.opts.Backend.String(),
+		Components: append([]string{}, pkg.exported...),
+	}
+	sort.Strings(m.Components)
+	b, err := json.MarshalIndent(m, "", "\t")
+	if err != nil {
+		panic(err) // code bug: all fields are marshalable
+	}
+	return append(b, '\n')
+}
+
+// loadManifests reads the manifests listed in Options.Manifests.
+func (g *generator) loadManifests() error {
+	g.manifests = make(map[string]*Manifest)
+	for _, p := range g.opts.Manifests {
+		g.addDep(p)
+		f, err := g.open(p)
+		if err != nil {
+			return err
+		}
+		b, err := ioutil.ReadAll(f)
+		f.Close()
+		if err != nil {
+			return err
+		}
+		var m Manifest
+		if err := json.Unmarshal(b, &m); err != nil {
+			return Error{
+				Path: p,
+				Err:  fmt.Errorf("parse manifest: %w", err),
+			}
+		}
+		if m.Backend != g.opts.Backend.String() {
+			return Error{
+				Path: p,
+				Err:  fmt.Errorf("manifest is for backend %q, not %q", m.Backend, g.opts.Backend),
+			}
+		}
+		if other, ok := g.manifests[m.ImportPath]; ok {
+			return Error{
+				Path: p,
+				Err:  fmt.Errorf("duplicate manifest for package %q (also in %s)", m.ImportPath, other.path),
+			}
+		}
+		m.path = p
+		g.manifests[m.ImportPath] = &m
+	}
+	return nil
+}
+
+// resolveComponent resolves the value of a component attribute, such as
+// "github.com/acme/ui.Button", to the manifest of the package and the type
+// name.
+func (g *generator) resolveComponent(val string) (*Manifest, string, error) {
+	i := strings.LastIndex(val, ".")
+	if i <= 0 || i < strings.LastIndex(val, "/") {
+		return nil, "", fmt.Errorf("component %q must be of the form <import-path>.<type>", val)
+	}
+	importPath, typeName := val[:i], val[i+1:]
+
+	m, ok := g.manifests[importPath]
+	if !ok {
+		return nil, "", fmt.Errorf("no manifest for package %q (hint: add its manifest to the manifests option)", importPath)
+	}
+	for _, c := range m.Components {
+		if c == typeName {
+			return m, typeName, nil
+		}
+	}
+	return nil, "", fmt.Errorf("component %s not in manifest for package %q", typeName, importPath)
+}
@@ -0,0 +1,173 @@
+package webgen
+
+import (
+	"fmt"
+	"go/token"
+	"go/types"
+	"path/filepath"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// NamingStrategy determines the Go type names of components.
+type NamingStrategy int
+
+const (
+	// NamingBaseName names a component after its file name, without the
+	// extension. For example, the type of admin/Button.html is Button.
+	NamingBaseName NamingStrategy = iota
+
+	// NamingDirPrefix names a component after its file name, prefixed by
+	// the names of the directories that contain it, relative to
+	// Options.Root (or to its package's directory, with
+	// Options.PackagePerDir). For example, the type of admin/Button.html
+	// is AdminButton, and the type of admin/button.html is adminButton.
+	NamingDirPrefix
+)
+
+func (s NamingStrategy) String() string {
+	switch s {
+	case NamingBaseName:
+		return "base"
+	case NamingDirPrefix:
+		return "dir-prefix"
+	}
+	return fmt.Sprintf("NamingStrategy(%d)", int(s))
+}
+
+// typeName returns the type name of the component file at path. It is the
+// name specified in the file, if the file has been generated, or otherwise
+// the name derived from the path by the naming strategy.
+func (g *generator) typeName(path string) string {
+	if name, ok := g.typeNames[path]; ok {
+		return name
+	}
+
+	name := componentTypeName(filepath.Base(path))
+	switch g.opts.Naming {
+	case NamingBaseName:
+	case NamingDirPrefix:
+		name = g.dirPrefix(path, name)
+	default:
+		panic("unknown naming strategy")
+	}
+	return fixTypeName(name, g.opts.ExportNames)
+}
+
+// dirPrefix prefixes name with the names of the directories of the
+// component file at path, relative to its package's directory. The
+// exportedness of name is retained.
+func (g *generator) dirPrefix(path, name string) string {
+	pkg, err := g.packageOf(path)
+	if err != nil {
+		return name
+	}
+	rel, err := filepath.Rel(filepath.Join(g.opts.Root, pkg.dir), filepath.Dir(path))
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return name
+	}
+
+	var b strings.Builder
+	for _, w := range identWords(rel) {
+		b.WriteString(toUppperFirstRune(w))
+	}
+	b.WriteString(toUppperFirstRune(name))
+	prefixed := b.String()
+	if !isExportedName(name) {
+		prefixed = toLowerFirstRune(prefixed)
+	}
+	return prefixed
+}
+
+// componentTypeName returns the type name for the component file name. The
+// extension is removed, and words separated by characters such as "-", "_",
+// and " " are joined in camel case, so that "user-card.html" becomes
+// userCard and "User_card.html" becomes UserCard. The result may need
+// fixTypeName.
+func componentTypeName(filename string) string {
+	// Remove what we assume to be the extension.
+	idx := strings.LastIndex(filename, ".")
+	if idx != -1 {
+		filename = filename[:idx]
+	}
+
+	words := identWords(filename)
+	for i := 1; i < len(words); i++ {
+		words[i] = toUppperFirstRune(words[i])
+	}
+	return strings.Join(words, "")
+}
+
+// identWords splits s into words, which are separated by characters other
+// than letters and digits.
+func identWords(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// fixTypeName makes a type name derived from a file name valid, if
+// possible: a name that starts with a digit is prefixed by "x", the name is
+// exported if export is true, and a name that is a Go keyword or
+// predeclared identifier is suffixed by "_". Empty names are left empty.
+func fixTypeName(name string, export bool) string {
+	if name == "" {
+		return name
+	}
+	if r, _ := utf8.DecodeRuneInString(name); unicode.IsDigit(r) {
+		name = "x" + name
+	}
+	if export {
+		name = toUppperFirstRune(name)
+	}
+	if token.IsKeyword(name) || types.Universe.Lookup(name) != nil {
+		name += "_"
+	}
+	return name
+}
+
+// isValidTypeName reports whether name can be used as the type name of a
+// component. Names that start with "_" are reserved for generated
+// declarations shared by components.
+func isValidTypeName(name string) bool {
+	return token.IsIdentifier(name) &&
+		!strings.HasPrefix(name, "_") &&
+		types.Universe.Lookup(name) == nil
+}
+
+// claimTypeName records the type name of the component file at path, and
+// returns an error if the name is invalid, or if another component in the
+// package has the same type name. Names that differ only in the case of
+// the first letter are considered the same, since the names of other
+// generated identifiers, such as hydrate functions, are derived from them.
+func (g *generator) claimTypeName(pkg *viewsPackage, path, name string) error {
+	if !isValidTypeName(name) {
+		return Error{
+			Path: path,
+			Err:  fmt.Errorf(`cannot derive a type name from file name %q (hint: specify one with <meta name="webgen:name" content="...">)`, filepath.Base(path)),
+		}
+	}
+	key := toUppperFirstRune(name)
+	if other, ok := pkg.types[key]; ok && other != path {
+		return Error{
+			Path: path,
+			Err:  fmt.Errorf("type name %s collides with component %s (hint: rename one of the files, or use the dir-prefix naming strategy)", name, other),
+		}
+	}
+	if importPath := pkg.importName(name); importPath != "" {
+		return Error{
+			Path: path,
+			Err:  fmt.Errorf("type name %s collides with the name of imported package %s (hint: rename the file, or specify another type name)", name, importPath),
+		}
+	}
+	if pkg.types == nil {
+		pkg.types = make(map[string]string)
+	}
+	pkg.types[key] = path
+	if g.typeNames == nil {
+		g.typeNames = make(map[string]string)
+	}
+	g.typeNames[path] = name
+	return nil
+}
@@ -0,0 +1,298 @@
+package webgen
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+	"go/types"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// viewsPackage is a Go package of generated views. Unless
+// Options.PackagePerDir is set, there is a single package.
+type viewsPackage struct {
+	dir        string // directory relative to Options.Root; "." for the root
+	name       string
+	importPath string
+
+	buf      bytes.Buffer          // views, excluding the header
+	files    []componentFile       // only if Options.FilePerComponent
+	types    map[string]string     // type name, with upper-case first rune -> component file path
+	imports  map[string]importSpec // import path -> import
+	exported []string              // exported component type names with views
+}
+
+// importSpec is an import in the views output.
+type importSpec struct {
+	Name string // empty if the package name matches the import path
+	Path string
+
+	local string // name that the package is referred to by
+}
+
+// reservedImportNames are the names that an import in the views output
+// must not have, besides predeclared identifiers and names ending with a
+// digit, which may be variables: the names of the packages that headers
+// import, and of parameters and variables in generated code.
+var reservedImportNames = map[string]bool{
+	// Imports
+	"canvas":  true,
+	"dom":     true,
+	"errors":  true,
+	"fmt":     true,
+	"html":    true,
+	"io":      true,
+	"js":      true,
+	"media":   true,
+	"strings": true,
+	"webapi":  true,
+
+	// Parameters and variables
+	"b":           true,
+	"c":           true,
+	"css":         true,
+	"e":           true,
+	"elems":       true,
+	"err":         true,
+	"f":           true,
+	"i":           true,
+	"includes":    true,
+	"injected":    true,
+	"m":           true,
+	"marker":      true,
+	"op":          true,
+	"open":        true,
+	"ops":         true,
+	"parent":      true,
+	"path":        true,
+	"placeholder": true,
+	"props":       true,
+	"r":           true,
+	"root":        true,
+	"roots":       true,
+	"s":           true,
+	"t":           true,
+	"tagName":     true,
+	"text":        true,
+	"v":           true,
+	"w":           true,
+}
+
+// addImport adds an import of the package with the import path and name to
+// the views output of pkg, and returns the name to refer to the package
+// by. If the name is reserved, or taken by another import or a component
+// type in pkg, the import is given a unique name instead.
+func (g *generator) addImport(pkg *viewsPackage, importPath, name string) string {
+	if spec, ok := pkg.imports[importPath]; ok {
+		return spec.local
+	}
+	local := name
+	for i := 1; !g.availableImportName(pkg, local, local != name); i++ {
+		local = name + "pkg"
+		if i > 1 {
+			local += strconv.Itoa(i)
+		}
+	}
+
+	spec := importSpec{Path: importPath, local: local}
+	if path.Base(importPath) != local {
+		spec.Name = local
+	}
+	if pkg.imports == nil {
+		pkg.imports = make(map[string]importSpec)
+	}
+	pkg.imports[importPath] = spec
+	return local
+}
+
+// availableImportName reports whether an import in pkg can have the name.
+// Unless generated is set, names ending with a digit are not available.
+func (g *generator) availableImportName(pkg *viewsPackage, name string, generated bool) bool {
+	if reservedImportNames[name] || types.Universe.Lookup(name) != nil {
+		return false
+	}
+	if r, _ := utf8.DecodeLastRuneInString(name); !generated && unicode.IsDigit(r) {
+		return false
+	}
+	return pkg.importName(name) == "" && !g.declares(pkg, name)
+}
+
+// declares reports whether a component in pkg has the type name.
+func (g *generator) declares(pkg *viewsPackage, name string) bool {
+	p, ok := pkg.types[toUppperFirstRune(name)]
+	return ok && g.typeNames[p] == name
+}
+
+// importName returns the import path of the import in pkg with the name,
+// or "" if there is none.
+func (pkg *viewsPackage) importName(name string) string {
+	for _, spec := range pkg.imports {
+		if spec.local == name {
+			return spec.Path
+		}
+	}
+	return ""
+}
+
+// sortedImports returns the package's imports, sorted by path.
+func (p *viewsPackage) sortedImports() []importSpec {
+	var specs []importSpec
+	for _, s := range p.imports {
+		specs = append(specs, s)
+	}
+	sort.Slice(specs, func(i, j int) bool {
+		return specs[i].Path < specs[j].Path
+	})
+	return specs
+}
+
+// packageOf returns the package for the component file at path.
+func (g *generator) packageOf(p string) (*viewsPackage, error) {
+	dir := "."
+	if g.opts.PackagePerDir {
+		rel, err := filepath.Rel(g.opts.Root, filepath.Dir(p))
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return nil, fmt.Errorf("component file is outside root directory %s", g.opts.Root)
+		}
+		dir = rel
+	}
+
+	if pkg, ok := g.pkgs[dir]; ok {
+		return pkg, nil
+	}
+	pkg := &viewsPackage{
+		dir:        dir,
+		name:       g.opts.Package,
+		importPath: g.opts.ImportPath,
+	}
+	if dir != "." {
+		pkg.name = packageName(filepath.Base(dir))
+		pkg.importPath = path.Join(g.opts.ImportPath, filepath.ToSlash(dir))
+	}
+	if g.pkgs == nil {
+		g.pkgs = make(map[string]*viewsPackage)
+	}
+	g.pkgs[dir] = pkg
+	return pkg, nil
+}
+
+// packageName returns a package name for the directory name. Characters
+// other than letters and digits are removed.
+func packageName(dirName string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(dirName) {
+		if ('a' <= r && r <= 'z') || ('0' <= r && r <= '9' && b.Len() != 0) {
+			b.WriteRune(r)
+		}
+	}
+	name := b.String()
+	if name == "" || token.IsKeyword(name) {
+		name += "pkg"
+	}
+	return name
+}
+
+// sortedPackages returns the packages, sorted by directory.
+func (g *generator) sortedPackages() []*viewsPackage {
+	var pkgs []*viewsPackage
+	for _, p := range g.pkgs {
+		pkgs = append(pkgs, p)
+	}
+	sort.Slice(pkgs, func(i, j int) bool {
+		return pkgs[i].dir < pkgs[j].dir
+	})
+	return pkgs
+}
+
+// views returns the formatted views output for the package.
+func (g *generator) views(pkg *viewsPackage) []byte {
+	// The header is written last, since it depends on the imports needed
+	// by the components.
+	var views bytes.Buffer
+	headerTpl := ssrHeaderTpl
+	if d := g.dialect(); d != nil {
+		headerTpl = d.header()
+	}
+	err := headerTpl.Execute(&views, viewsHeaderArgs{
+		Package:        pkg.name,
+		Imports:        pkg.sortedImports(),
+		EmbedCSS:       g.embedCSS(),
+		Hydrate:        g.opts.Hydrate,
+		CloneTemplates: g.opts.CloneTemplates,
+		Compact:        g.opts.CompactConstructors && !g.opts.CloneTemplates,
+	})
+	if err != nil {
+		panic(err) // code bug: check template args?
+	}
+	views.Write(pkg.buf.Bytes())
+
+	// Uncomment to debug.
+	// log.Println(views.String())
+
+	// Run through gofmt-style formatting.
+	b, err := format.Source(views.Bytes())
+	if err != nil {
+		panic(err) // code bug: we may have generated bad code
+	}
+	return b
+}
+
+// checkImportCycles returns an error if packages import each other, which
+// Go does not allow.
+func (g *generator) checkImportCycles() error {
+	byImportPath := make(map[string]*viewsPackage)
+	for _, p := range g.pkgs {
+		byImportPath[p.importPath] = p
+	}
+
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	state := make(map[*viewsPackage]int)
+	var stack []string
+
+	var visit func(p *viewsPackage) error
+	visit = func(p *viewsPackage) error {
+		switch state[p] {
+		case visiting:
+			i := 0
+			for stack[i] != p.dir {
+				i++
+			}
+			cycle := append(stack[i:len(stack):len(stack)], p.dir)
+			return Error{
+				Path: p.dir,
+				Err:  fmt.Errorf("cycle in package imports (%s)", strings.Join(cycle, " -> ")),
+			}
+		case visited:
+			return nil
+		}
+		state[p] = visiting
+		stack = append(stack, p.dir)
+		for _, spec := range p.sortedImports() {
+			if q, ok := byImportPath[spec.Path]; ok {
+				if err := visit(q); err != nil {
+					return err
+				}
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[p] = visited
+		return nil
+	}
+
+	for _, p := range g.sortedPackages() {
+		if err := visit(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,173 @@
+package webgen
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+)
+
+// sourceMap builds a version 3 source map.
+// See https://sourcemaps.info/spec.html.
+type sourceMap struct {
+	sources  []string
+	contents []string
+	index    map[string]int // source name -> index in sources
+	lines    [][]mapSegment // generated line -> segments in column order
+}
+
+type mapSegment struct {
+	genCol            int
+	source, line, col int
+}
+
+func newSourceMap() *sourceMap {
+	return &sourceMap{
+		index: make(map[string]int),
+	}
+}
+
+// addSource adds a source file, by its slash-separated name in the source
+// map, if not already present, and returns its index.
+func (m *sourceMap) addSource(name string, content []byte) int {
+	if i, ok := m.index[name]; ok {
+		return i
+	}
+	m.index[name] = len(m.sources)
+	m.sources = append(m.sources, name)
+	m.contents = append(m.contents, string(content))
+	return len(m.sources) - 1
+}
+
+// add adds a mapping from the generated position to the source position.
+// Positions are zero-based. Mappings for a generated line must be added in
+// increasing column order.
+func (m *sourceMap) add(genLine, genCol, source, line, col int) {
+	for len(m.lines) <= genLine {
+		m.lines = append(m.lines, nil)
+	}
+	m.lines[genLine] = append(m.lines[genLine], mapSegment{genCol, source, line, col})
+}
+
+// encode returns the JSON encoding of the source map.
+func (m *sourceMap) encode() []byte {
+	v := struct {
+		Version        int      `json:"version"`
+		Sources        []string `json:"sources"`
+		SourcesContent []string `json:"sourcesContent"`
+		Names          []string `json:"names"`
+		Mappings       string   `json:"mappings"`
+	}{
+		Version:        3,
+		Sources:        m.sources,
+		SourcesContent: m.contents,
+		Names:          []string{},
+		Mappings:       m.mappings(),
+	}
+	if v.Sources == nil {
+		v.Sources = []string{}
+		v.SourcesContent = []string{}
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false) // sources contain HTML
+	if err := enc.Encode(v); err != nil {
+		panic(err) // code bug: all fields are marshalable
+	}
+	return buf.Bytes()
+}
+
+func (m *sourceMap) mappings() string {
+	var b strings.Builder
+	var prev mapSegment // source, line, col are relative across lines
+	for i, segs := range m.lines {
+		if i != 0 {
+			b.WriteByte(';')
+		}
+		prevGenCol := 0 // genCol is relative within a line
+		for j, s := range segs {
+			if j != 0 {
+				b.WriteByte(',')
+			}
+			writeVLQ(&b, s.genCol-prevGenCol)
+			writeVLQ(&b, s.source-prev.source)
+			writeVLQ(&b, s.line-prev.line)
+			writeVLQ(&b, s.col-prev.col)
+			prevGenCol = s.genCol
+			prev = s
+		}
+	}
+	return b.String()
+}
+
+const base64Digits = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/"
+
+func writeVLQ(b *strings.Builder, v int) {
+	// The sign is stored in the least significant bit.
+	u := uint(v) << 1
+	if v < 0 {
+		u = uint(-v)<<1 | 1
+	}
+	for {
+		digit := u & 0x1f
+		u >>= 5
+		if u != 0 {
+			digit |= 0x20 // continuation bit
+		}
+		b.WriteByte(base64Digits[digit])
+		if u == 0 {
+			return
+		}
+	}
+}
+
+// mappedWriter is a buffer that tracks the current generated position, so
+// that mappings from that position can be added to a source map.
+type mappedWriter struct {
+	bytes.Buffer
+	sm        *sourceMap // nil if no mappings are recorded
+	line, col int        // current generated position; col is in UTF-16 code units
+}
+
+func (w *mappedWriter) write(s string) {
+	w.WriteString(s)
+	if i := strings.LastIndexByte(s, '\n'); i != -1 {
+		w.line += strings.Count(s, "\n")
+		w.col = 0
+		s = s[i+1:]
+	}
+	w.col += utf16Len(s)
+}
+
+// mark records a mapping from the current generated position to the
+// source position.
+func (w *mappedWriter) mark(source, line, col int) {
+	if w.sm == nil {
+		return
+	}
+	w.sm.add(w.line, w.col, source, line, col)
+}
+
+// utf16Len returns the length of s in UTF-16 code units, the unit of columns
+// in source maps.
+func utf16Len(s string) int {
+	n := 0
+	for _, r := range s {
+		if r >= 0x10000 {
+			n += 2 // surrogate pair
+		} else {
+			n++
+		}
+	}
+	return n
+}
+
+// textPosition returns the zero-based line and column (in UTF-16 code units)
+// of the byte offset in text, given that text itself begins at the position
+// line, col.
+func textPosition(text []byte, offset, line, col int) (int, int) {
+	before := text[:offset]
+	if i := bytes.LastIndexByte(before, '\n'); i != -1 {
+		return line + bytes.Count(before, newline), utf16Len(string(before[i+1:]))
+	}
+	return line, col + utf16Len(string(before))
+}
@@ -0,0 +1,195 @@
+package webgen
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"strings"
+	"text/template"
+)
+
+// writeSSRComponent writes the Go props type and render function for the
+// component, for server-side rendering. If hydrate is true, the root
+// elements are marked for hydration.
+func writeSSRComponent(w io.Writer, c *component, hydrate bool) {
+	if !c.hasView() {
+		return
+	}
+
+	fmt.Fprintf(w, "// source: %s\n\n", c.path)
+
+	propsName := propsTypeName(c.typeName)
+	fmt.Fprintf(w, "type %s struct {\n", propsName)
+	for _, r := range c.refs {
+		if r.kind == includeNode {
+			fmt.Fprintf(w, "%s *%s\n", r.ref, r.includeQualifier()+propsTypeName(r.includeTypeName))
+		} else {
+			fmt.Fprintf(w, "%s func(w io.Writer) error\n", r.ref)
+		}
+	}
+	fmt.Fprint(w, "}\n\n")
+
+	fmt.Fprintf(w, "func %s(w io.Writer, props *%s) error {\n", renderFuncName(c.typeName), propsName)
+	if len(c.refs) != 0 {
+		fmt.Fprintf(w, "if props == nil {\n")
+		fmt.Fprintf(w, "props = &%s{}\n", propsName)
+		fmt.Fprintf(w, "}\n")
+	}
+	fmt.Fprintf(w, "_w := &_writer{w: w}\n")
+	e := ssrEmitter{w: w}
+	if hydrate {
+		e.marker = c.typeName
+	}
+	e.nodes(c.roots, "")
+	e.flush()
+	fmt.Fprint(w, "return _w.err\n")
+	fmt.Fprint(w, "}\n\n")
+}
+
+// ssrEmitter writes the statements of a render function. Adjacent static
+// markup is combined into a single write.
+type ssrEmitter struct {
+	w      io.Writer
+	static strings.Builder // pending static markup
+	marker string          // data-w marker for root elements, if any
+}
+
+func (e *ssrEmitter) s(s string) {
+	e.static.WriteString(s)
+}
+
+// flush writes the pending static markup, if any.
+func (e *ssrEmitter) flush() {
+	if e.static.Len() == 0 {
+		return
+	}
+	fmt.Fprintf(e.w, "_w.s(%s)\n", goStringLiteral(e.static.String()))
+	e.static.Reset()
+}
+
+// nodes writes statements that render the nodes. parentTag is the tag name
+// of the parent element, or empty for root nodes.
+func (e *ssrEmitter) nodes(nodes []*node, parentTag string) {
+	for _, n := range nodes {
+		switch n.kind {
+		case elementNode:
+			e.element(n, parentTag == "")
+
+		case textNode:
+			if isRawTextElement(parentTag) {
+				e.s(n.text)
+			} else {
+				e.s(html.EscapeString(n.text))
+			}
+
+		case includeNode:
+			e.flush()
+			props := "nil"
+			if n.ref != "" {
+				props = "props." + n.ref
+			}
+			fmt.Fprintf(e.w, "_w.f(func(w io.Writer) error { return %s(w, %s) })\n", n.includeQualifier()+renderFuncName(n.includeTypeName), props)
+		}
+	}
+}
+
+func (e *ssrEmitter) element(n *node, isRoot bool) {
+	e.s("<" + n.tag)
+	for _, a := range n.attrs {
+		e.s(" " + a.key + `="` + html.EscapeString(a.val) + `"`)
+	}
+	if isRoot && e.marker != "" {
+		e.s(` data-w="` + html.EscapeString(e.marker) + `"`)
+	}
+
+	if isVoidElement(n.tag) {
+		if n.ref != "" {
+			// The ref function writes additional attributes.
+			e.flush()
+			fmt.Fprintf(e.w, "if props.%s != nil {\n", n.ref)
+			fmt.Fprintf(e.w, "_w.f(props.%s)\n", n.ref)
+			fmt.Fprintf(e.w, "}\n")
+		}
+		e.s(">")
+		return
+	}
+
+	e.s(">")
+	if n.ref != "" {
+		// The ref function, if set, replaces the children.
+		e.flush()
+		fmt.Fprintf(e.w, "if props.%s != nil {\n", n.ref)
+		fmt.Fprintf(e.w, "_w.f(props.%s)\n", n.ref)
+		if len(n.children) != 0 {
+			fmt.Fprintf(e.w, "} else {\n")
+			e.nodes(n.children, n.tag)
+			e.flush()
+		}
+		fmt.Fprintf(e.w, "}\n")
+	} else {
+		e.nodes(n.children, n.tag)
+	}
+	e.s("</" + n.tag + ">")
+}
+
+// isVoidElement reports whether the element has no end tag.
+// See https://html.spec.whatwg.org/multipage/syntax.html#void-elements.
+func isVoidElement(tagName string) bool {
+	switch tagName {
+	case "area", "base", "br", "col", "embed", "hr", "img", "input",
+		"link", "meta", "param", "source", "track", "wbr":
+		return true
+	}
+	return false
+}
+
+// isRawTextElement reports whether the text content of the element is not
+// escaped in HTML, matching the elements whose text the tokenizer does not
+// unescape.
+func isRawTextElement(tagName string) bool {
+	switch tagName {
+	case "iframe", "noembed", "noframes", "noscript", "plaintext", "script", "style", "xmp":
+		return true
+	}
+	return false
+}
+
+func propsTypeName(typeName string) string {
+	return typeName + "Props"
+}
+
+func renderFuncName(typeName string) string {
+	if isExportedName(typeName) {
+		return "Render" + typeName
+	}
+	return "render" + toUppperFirstRune(typeName)
+}
+
+const ssrHeader = `package {{.Package}}
+
+// Code generated by webgen. DO NOT EDIT.
+
+import (
+	"io"
+` + importSpecs + `)
+
+// _writer writes to w until the first error, which it records.
+type _writer struct {
+	w   io.Writer
+	err error
+}
+
+func (w *_writer) s(s string) {
+	if w.err == nil {
+		_, w.err = io.WriteString(w.w, s)
+	}
+}
+
+func (w *_writer) f(f func(w io.Writer) error) {
+	if w.err == nil {
+		w.err = f(w.w)
+	}
+}
+`
+
+var ssrHeaderTpl = template.Must(template.New("").Parse(ssrHeader))
@@ -0,0 +1,188 @@
+package webgen
+
+import (
+	"fmt"
+	"strconv"
+	"text/template"
+)
+
+// syscallJSDialect generates code that uses only the syscall/js package.
+type syscallJSDialect struct{}
+
+func (syscallJSDialect) header() *template.Template        { return syscallJSHeaderTpl }
+func (syscallJSDialect) elementType() string               { return "js.Value" }
+func (syscallJSDialect) null() string                      { return "js.Null()" }
+func (syscallJSDialect) refType(n *node) (string, bool)    { return "", false }
+func (syscallJSDialect) refValue(n *node, v string) string { return v }
+
+func (syscallJSDialect) createElement(tag string) string {
+	return fmt.Sprintf("_document.Call(\"createElement\", %q)", tag)
+}
+
+func (syscallJSDialect) setAttribute(v, key, val string) string {
+	return fmt.Sprintf("%s.Call(\"setAttribute\", %q, %q)", v, key, val)
+}
+
+func (syscallJSDialect) setTextContent(v, text, textVar string) string {
+	return fmt.Sprintf("%s.Set(\"textContent\", %s)", v, strconv.Quote(text))
+}
+
+func (syscallJSDialect) appendText(v, text, textVar string) string {
+	return fmt.Sprintf("%s.Call(\"appendChild\", _document.Call(\"createTextNode\", %s))", v, strconv.Quote(text))
+}
+
+func (syscallJSDialect) appendChild(parent, child string) string {
+	return fmt.Sprintf("%s.Call(\"appendChild\", %s)", parent, child)
+}
+
+func (syscallJSDialect) firstElementChild(v string) string {
+	return v + `.Get("firstElementChild")`
+}
+
+func (syscallJSDialect) nextElementSibling(v string) string {
+	return v + `.Get("nextElementSibling")`
+}
+
+// syscallJSHeader is the header for syscall/js dialects. It uses the
+// "imports" and "hydrate" templates, which are defined per dialect.
+const syscallJSHeader = `package {{.Package}}
+
+// Code generated by webgen. DO NOT EDIT.
+
+import (
+{{- if .Hydrate}}{{template "imports"}}{{end}}
+	"syscall/js"
+` + importSpecs + `)
+
+var (
+	_document = js.Global().Get("document")
+)
+{{if .EmbedCSS}}
+// _injectCSS appends a <style> element containing css to the document's
+// <head>, unless *injected is already true.
+func _injectCSS(injected *bool, css string) {
+	if *injected {
+		return
+	}
+	*injected = true
+	style := _document.Call("createElement", "style")
+	style.Set("textContent", css)
+	_document.Get("head").Call("appendChild", style)
+}
+{{end -}}
+{{if .CloneTemplates}}
+// _template is component markup, which is parsed into a <template> element
+// when first cloned.
+type _template struct {
+	html    string
+	content js.Value
+}
+
+// clone returns the root elements of a deep copy of the template's content.
+func (t *_template) clone() []js.Value {
+	if t.content.IsUndefined() {
+		e := _document.Call("createElement", "template")
+		e.Set("innerHTML", t.html)
+		t.content = e.Get("content")
+	}
+	f := t.content.Call("cloneNode", true)
+	var roots []js.Value
+	for e := f.Get("firstElementChild"); !e.IsNull(); e = e.Get("nextElementSibling") {
+		roots = append(roots, e)
+	}
+	return roots
+}
+
+// _at returns the descendant of roots[root] at the path of child element
+// indices.
+func _at(roots []js.Value, root int, path ...int) js.Value {
+	e := roots[root]
+	for _, i := range path {
+		e = e.Get("children").Index(i)
+	}
+	return e
+}
+
+// _replace replaces the placeholder element with the elements.
+func _replace(placeholder js.Value, elems []js.Value) {
+	parent := placeholder.Get("parentNode")
+	for _, e := range elems {
+		parent.Call("insertBefore", e, placeholder)
+	}
+	placeholder.Call("remove")
+}
+{{end -}}
+{{if .Compact}}
+// _build creates elements according to the build operations, and returns
+// them in the order created. The roots of included components are appended
+// in the order given. See the webgen source for the operations.
+func _build(ops []string, includes ...[]js.Value) []js.Value {
+	var elems, open []js.Value
+	for _, op := range ops {
+		switch op[0] {
+		case '<':
+			e := _document.Call("createElement", op[1:])
+			if len(open) != 0 {
+				open[len(open)-1].Call("appendChild", e)
+			}
+			elems = append(elems, e)
+			open = append(open, e)
+		case '>':
+			open = open[:len(open)-1]
+		case '=':
+			for i := 1; i < len(op); i++ {
+				if op[i] == '=' {
+					open[len(open)-1].Call("setAttribute", op[1:i], op[i+1:])
+					break
+				}
+			}
+		case '"':
+			open[len(open)-1].Call("appendChild", _document.Call("createTextNode", op[1:]))
+		case '+':
+			for _, r := range includes[0] {
+				open[len(open)-1].Call("appendChild", r)
+			}
+			includes = includes[1:]
+		}
+	}
+	return elems
+}
+{{end -}}
+{{if .Hydrate}}{{template "hydrate"}}{{end -}}
+`
+
+const syscallJSHydrate = `
+{{- define "imports"}}
+	"fmt"
+	"strings"
+{{- end}}
+
+{{- define "hydrate"}}
+// _hydrateCheck returns an error unless e is an element with the tag name
+// and, if marker is non-empty, the data-w marker.
+func _hydrateCheck(e js.Value, tagName, marker string) error {
+	if e.IsNull() {
+		return fmt.Errorf("hydrate: missing <%s> element", tagName)
+	}
+	if t := e.Get("tagName").String(); !strings.EqualFold(t, tagName) {
+		return fmt.Errorf("hydrate: expected <%s> element, found <%s>", tagName, strings.ToLower(t))
+	}
+	if marker != "" {
+		if m := e.Call("getAttribute", "data-w"); m.IsNull() || m.String() != marker {
+			return fmt.Errorf("hydrate: expected <%s> element with data-w=%q", tagName, marker)
+		}
+	}
+	return nil
+}
+
+// _hydrateEnd returns an error if e, the element after the last expected
+// element, is not null.
+func _hydrateEnd(e js.Value) error {
+	if !e.IsNull() {
+		return fmt.Errorf("hydrate: unexpected <%s> element", strings.ToLower(e.Get("tagName").String()))
+	}
+	return nil
+}
+{{end}}`
+
+var syscallJSHeaderTpl = template.Must(template.New("").Parse(syscallJSHeader + syscallJSHydrate))
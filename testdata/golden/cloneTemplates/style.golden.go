@@ -0,0 +1,107 @@
+package ui
+
+// Code generated by webgen. DO NOT EDIT.
+
+import (
+	"github.com/gowebapi/webapi"
+	"github.com/gowebapi/webapi/dom"
+	"github.com/gowebapi/webapi/html"
+	"github.com/gowebapi/webapi/html/canvas"
+	"github.com/gowebapi/webapi/html/media"
+)
+
+type (
+	_ *webapi.Document // prevent unused import errors
+	_ *dom.Element
+	_ *html.HTMLDivElement
+	_ *canvas.HTMLCanvasElement
+	_ *media.HTMLAudioElement
+)
+
+var (
+	_document = webapi.GetDocument()
+)
+
+// _injectCSS appends a <style> element containing css to the document's
+// <head>, unless *injected is already true.
+func _injectCSS(injected *bool, css string) {
+	if *injected {
+		return
+	}
+	*injected = true
+	style := _document.CreateElement("style", nil)
+	style.SetTextContent(&css)
+	_document.Head().AppendChild(&style.Node)
+}
+
+// _template is component markup, which is parsed into a <template> element
+// when first cloned.
+type _template struct {
+	html    string
+	content *dom.DocumentFragment
+}
+
+// clone returns the root elements of a deep copy of the template's content.
+func (t *_template) clone() []*dom.Element {
+	if t.content == nil {
+		e := _document.CreateElement("template", nil)
+		e.SetInnerHTML(t.html)
+		t.content = html.HTMLTemplateElementFromJS(e).Content()
+	}
+	deep := true
+	f := dom.DocumentFragmentFromJS(t.content.CloneNode(&deep))
+	var roots []*dom.Element
+	for e := f.FirstElementChild(); e != nil; e = e.NextElementSibling() {
+		roots = append(roots, e)
+	}
+	return roots
+}
+
+// _at returns the descendant of roots[root] at the path of child element
+// indices.
+func _at(roots []*dom.Element, root int, path ...int) *dom.Element {
+	e := roots[root]
+	for _, i := range path {
+		e = e.Children().Item(uint(i))
+	}
+	return e
+}
+
+// _replace replaces the placeholder element with the elements.
+func _replace(placeholder *dom.Element, elems []*dom.Element) {
+	parent := placeholder.ParentNode()
+	for _, e := range elems {
+		parent.InsertBefore(&e.Node, &placeholder.Node)
+	}
+	placeholder.Remove()
+}
+
+// source: testdata/standalone/style.html
+
+type style struct {
+	roots []*dom.Element
+}
+
+const _styleCSS = `.d {
+	font-family: "Inter", sans-serif;
+}`
+
+var _styleCSSInjected bool
+
+func injectStyleCSS() {
+	_injectCSS(&_styleCSSInjected, _styleCSS)
+}
+
+var _styleTemplate = &_template{html: `<div class="d"></div>`}
+
+func newStyle() *style {
+	roots := _styleTemplate.clone()
+	injectStyleCSS()
+	return &style{
+		roots: roots,
+	}
+}
+
+func (v *style) Roots() []*dom.Element {
+	return v.roots
+}
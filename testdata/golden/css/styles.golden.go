@@ -0,0 +1,64 @@
+package ui
+
+// Code generated by webgen. DO NOT EDIT.
+
+import (
+	"github.com/gowebapi/webapi"
+	"github.com/gowebapi/webapi/dom"
+	"github.com/gowebapi/webapi/html"
+	"github.com/gowebapi/webapi/html/canvas"
+	"github.com/gowebapi/webapi/html/media"
+)
+
+type (
+	_ *webapi.Document // prevent unused import errors
+	_ *dom.Element
+	_ *html.HTMLDivElement
+	_ *canvas.HTMLCanvasElement
+	_ *media.HTMLAudioElement
+)
+
+var (
+	_document = webapi.GetDocument()
+)
+
+// source: testdata/css/styles.html
+
+type styles struct {
+	roots []*dom.Element
+}
+
+func newStyles() *styles {
+	div0 := _document.CreateElement("div", nil)
+	div0.SetAttribute("class", "styles")
+	button0 := _document.CreateElement("button", nil)
+	button0.SetAttribute("class", "button")
+	stringliteral0 := "OK"
+	button0.SetTextContent(&stringliteral0)
+	div0.AppendChild(&button0.Node)
+	return &styles{
+		roots: []*dom.Element{div0},
+	}
+}
+
+func (v *styles) Roots() []*dom.Element {
+	return v.roots
+}
+
+// source: testdata/css/linkShared.html
+
+type linkShared struct {
+	roots []*dom.Element
+}
+
+func newLinkShared() *linkShared {
+	span0 := _document.CreateElement("span", nil)
+	span0.SetAttribute("class", "button")
+	return &linkShared{
+		roots: []*dom.Element{span0},
+	}
+}
+
+func (v *linkShared) Roots() []*dom.Element {
+	return v.roots
+}
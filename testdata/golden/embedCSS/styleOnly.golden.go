@@ -0,0 +1,47 @@
+package ui
+
+// Code generated by webgen. DO NOT EDIT.
+
+import (
+	"github.com/gowebapi/webapi"
+	"github.com/gowebapi/webapi/dom"
+	"github.com/gowebapi/webapi/html"
+	"github.com/gowebapi/webapi/html/canvas"
+	"github.com/gowebapi/webapi/html/media"
+)
+
+type (
+	_ *webapi.Document // prevent unused import errors
+	_ *dom.Element
+	_ *html.HTMLDivElement
+	_ *canvas.HTMLCanvasElement
+	_ *media.HTMLAudioElement
+)
+
+var (
+	_document = webapi.GetDocument()
+)
+
+// _injectCSS appends a <style> element containing css to the document's
+// <head>, unless *injected is already true.
+func _injectCSS(injected *bool, css string) {
+	if *injected {
+		return
+	}
+	*injected = true
+	style := _document.CreateElement("style", nil)
+	style.SetTextContent(&css)
+	_document.Head().AppendChild(&style.Node)
+}
+
+// source: testdata/standalone/styleOnly.html
+
+const _styleOnlyCSS = `.foo {
+	font-family: "Inter", sans-serif;
+}`
+
+var _styleOnlyCSSInjected bool
+
+func injectStyleOnlyCSS() {
+	_injectCSS(&_styleOnlyCSSInjected, _styleOnlyCSS)
+}
@@ -0,0 +1,52 @@
+package ui
+
+// Code generated by webgen. DO NOT EDIT.
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gowebapi/webapi"
+	"github.com/gowebapi/webapi/dom"
+	"github.com/gowebapi/webapi/html"
+	"github.com/gowebapi/webapi/html/canvas"
+	"github.com/gowebapi/webapi/html/media"
+)
+
+type (
+	_ *webapi.Document // prevent unused import errors
+	_ *dom.Element
+	_ *html.HTMLDivElement
+	_ *canvas.HTMLCanvasElement
+	_ *media.HTMLAudioElement
+)
+
+var (
+	_document = webapi.GetDocument()
+)
+
+// _hydrateCheck returns an error unless e is an element with the tag name
+// and, if marker is non-empty, the data-w marker.
+func _hydrateCheck(e *dom.Element, tagName, marker string) error {
+	if e == nil {
+		return fmt.Errorf("hydrate: missing <%s> element", tagName)
+	}
+	if !strings.EqualFold(e.TagName(), tagName) {
+		return fmt.Errorf("hydrate: expected <%s> element, found <%s>", tagName, strings.ToLower(e.TagName()))
+	}
+	if marker != "" {
+		if m := e.GetAttribute("data-w"); m == nil || *m != marker {
+			return fmt.Errorf("hydrate: expected <%s> element with data-w=%q", tagName, marker)
+		}
+	}
+	return nil
+}
+
+// _hydrateEnd returns an error if e, the element after the last expected
+// element, is not nil.
+func _hydrateEnd(e *dom.Element) error {
+	if e != nil {
+		return fmt.Errorf("hydrate: unexpected <%s> element", strings.ToLower(e.TagName()))
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package ui
+
+// Code generated by webgen. DO NOT EDIT.
+
+import (
+	"io"
+)
+
+// _writer writes to w until the first error, which it records.
+type _writer struct {
+	w   io.Writer
+	err error
+}
+
+func (w *_writer) s(s string) {
+	if w.err == nil {
+		_, w.err = io.WriteString(w.w, s)
+	}
+}
+
+func (w *_writer) f(f func(w io.Writer) error) {
+	if w.err == nil {
+		w.err = f(w.w)
+	}
+}
+
+// source: testdata/hydrate/card.html
+
+type cardProps struct {
+	Heading func(w io.Writer) error
+}
+
+func renderCard(w io.Writer, props *cardProps) error {
+	if props == nil {
+		props = &cardProps{}
+	}
+	_w := &_writer{w: w}
+	_w.s(`<div class="card" data-w="card"><h2>`)
+	if props.Heading != nil {
+		_w.f(props.Heading)
+	} else {
+		_w.s(`Card`)
+	}
+	_w.s(`</h2><p>Body</p></div><footer data-w="card"></footer>`)
+	return _w.err
+}
+
+// source: testdata/hydrate/page.html
+
+type pageProps struct {
+	Title   func(w io.Writer) error
+	Content func(w io.Writer) error
+	Hidden  *cardProps
+	Card    *cardProps
+	Name    func(w io.Writer) error
+}
+
+func renderPage(w io.Writer, props *pageProps) error {
+	if props == nil {
+		props = &pageProps{}
+	}
+	_w := &_writer{w: w}
+	_w.s(`<main data-w="page"><header><h1>`)
+	if props.Title != nil {
+		_w.f(props.Title)
+	} else {
+		_w.s(`Title`)
+	}
+	_w.s(`</h1>`)
+	_w.f(func(w io.Writer) error { return renderCard(w, nil) })
+	_w.s(`</header>Some text<section>`)
+	if props.Content != nil {
+		_w.f(props.Content)
+	} else {
+		_w.f(func(w io.Writer) error { return renderCard(w, props.Hidden) })
+	}
+	_w.s(`</section>`)
+	_w.f(func(w io.Writer) error { return renderCard(w, props.Card) })
+	_w.s(`<input type="text"`)
+	if props.Name != nil {
+		_w.f(props.Name)
+	}
+	_w.s(`></main>`)
+	return _w.err
+}
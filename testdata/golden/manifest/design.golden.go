@@ -0,0 +1,45 @@
+package design
+
+// Code generated by webgen. DO NOT EDIT.
+
+import (
+	"github.com/gowebapi/webapi"
+	"github.com/gowebapi/webapi/dom"
+	"github.com/gowebapi/webapi/html"
+	"github.com/gowebapi/webapi/html/canvas"
+	"github.com/gowebapi/webapi/html/media"
+)
+
+type (
+	_ *webapi.Document // prevent unused import errors
+	_ *dom.Element
+	_ *html.HTMLDivElement
+	_ *canvas.HTMLCanvasElement
+	_ *media.HTMLAudioElement
+)
+
+var (
+	_document = webapi.GetDocument()
+)
+
+// source: testdata/manifest/design/Button.html
+
+type Button struct {
+	Label *html.HTMLButtonElement
+	roots []*dom.Element
+}
+
+func NewButton() *Button {
+	button0 := _document.CreateElement("button", nil)
+	button0.SetAttribute("class", "Button")
+	stringliteral0 := "OK"
+	button0.SetTextContent(&stringliteral0)
+	return &Button{
+		Label: html.HTMLButtonElementFromJS(button0),
+		roots: []*dom.Element{button0},
+	}
+}
+
+func (v *Button) Roots() []*dom.Element {
+	return v.roots
+}
@@ -0,0 +1,52 @@
+package ui
+
+// Code generated by webgen. DO NOT EDIT.
+
+import (
+	"syscall/js"
+)
+
+var (
+	_document = js.Global().Get("document")
+)
+
+// _injectCSS appends a <style> element containing css to the document's
+// <head>, unless *injected is already true.
+func _injectCSS(injected *bool, css string) {
+	if *injected {
+		return
+	}
+	*injected = true
+	style := _document.Call("createElement", "style")
+	style.Set("textContent", css)
+	_document.Get("head").Call("appendChild", style)
+}
+
+// source: testdata/standalone/style.html
+
+type style struct {
+	roots []js.Value
+}
+
+const _styleCSS = `.d {
+	font-family: "Inter", sans-serif;
+}`
+
+var _styleCSSInjected bool
+
+func injectStyleCSS() {
+	_injectCSS(&_styleCSSInjected, _styleCSS)
+}
+
+func newStyle() *style {
+	div0 := _document.Call("createElement", "div")
+	div0.Call("setAttribute", "class", "d")
+	injectStyleCSS()
+	return &style{
+		roots: []js.Value{div0},
+	}
+}
+
+func (v *style) Roots() []js.Value {
+	return v.roots
+}
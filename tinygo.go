@@ -0,0 +1,64 @@
+package webgen
+
+import "text/template"
+
+// tinyGoDialect generates code for TinyGo. It is the same as
+// syscallJSDialect, except that the generated code does not use fmt or
+// other packages that depend on reflection, which TinyGo handles poorly
+// and which increase binary size.
+type tinyGoDialect struct {
+	syscallJSDialect
+}
+
+func (tinyGoDialect) header() *template.Template { return tinyGoHeaderTpl }
+
+const tinyGoHydrate = `
+{{- define "imports"}}
+	"errors"
+{{- end}}
+
+{{- define "hydrate"}}
+// _hydrateCheck returns an error unless e is an element with the tag name
+// and, if marker is non-empty, the data-w marker.
+func _hydrateCheck(e js.Value, tagName, marker string) error {
+	if e.IsNull() {
+		return errors.New("hydrate: missing <" + tagName + "> element")
+	}
+	if t := e.Get("tagName").String(); !_equalFold(t, tagName) {
+		return errors.New("hydrate: expected <" + tagName + "> element, found <" + _toLower(t) + ">")
+	}
+	if marker != "" {
+		if m := e.Call("getAttribute", "data-w"); m.IsNull() || m.String() != marker {
+			return errors.New("hydrate: expected <" + tagName + "> element with data-w=\"" + marker + "\"")
+		}
+	}
+	return nil
+}
+
+// _hydrateEnd returns an error if e, the element after the last expected
+// element, is not null.
+func _hydrateEnd(e js.Value) error {
+	if !e.IsNull() {
+		return errors.New("hydrate: unexpected <" + _toLower(e.Get("tagName").String()) + "> element")
+	}
+	return nil
+}
+
+// _equalFold reports whether s and t are equal under ASCII case folding.
+func _equalFold(s, t string) bool {
+	return _toLower(s) == _toLower(t)
+}
+
+// _toLower returns s with ASCII upper-case letters mapped to lower case.
+func _toLower(s string) string {
+	b := []byte(s)
+	for i, c := range b {
+		if 'A' <= c && c <= 'Z' {
+			b[i] = c + 'a' - 'A'
+		}
+	}
+	return string(b)
+}
+{{end}}`
+
+var tinyGoHeaderTpl = template.Must(template.New("").Parse(syscallJSHeader + tinyGoHydrate))
@@ -1,97 +1,71 @@
 package webgen
 
-import "fmt"
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"text/template"
 
-func webapiNames(tagName string) (typeName string, funcName string, ok bool) {
-	t, ok := webapiTagToType[tagName]
+	"github.com/littleroot/webgen/ir"
+)
+
+// webapiDialect generates code that uses the github.com/gowebapi/webapi
+// packages.
+type webapiDialect struct{}
+
+func (webapiDialect) header() *template.Template { return viewsHeaderTpl }
+func (webapiDialect) elementType() string        { return "*dom.Element" }
+func (webapiDialect) null() string               { return "nil" }
+
+func (webapiDialect) refType(n *node) (string, bool) {
+	t, _, ok := webapiNames(n)
 	if !ok {
-		return "", "", false
+		return "", false
 	}
-	typeName = fmt.Sprintf("%s.HTML%sElement", t.Package, t.Type)
-	funcName = fmt.Sprintf("%s.HTML%sElementFromJS", t.Package, t.Type)
-	return typeName, funcName, true
+	return "*" + t, true
+}
+
+func (webapiDialect) refValue(n *node, v string) string {
+	_, f, _ := webapiNames(n)
+	return fmt.Sprintf("%s(%s)", f, v)
 }
 
-// Obtained from webapi@v0.0.0-20201112202446-44407bcf554b.
-var webapiTagToType = map[string]struct {
-	Package string
-	Type    string
-}{
-	// "github.com/gowebapi/webapi/html/canvas"
-	"canvas": {"canvas", "Canvas"},
+func (webapiDialect) createElement(tag string) string {
+	return fmt.Sprintf("_document.CreateElement(%q, nil)", tag)
+}
+
+func (webapiDialect) setAttribute(v, key, val string) string {
+	return fmt.Sprintf("%s.SetAttribute(%q, %q)", v, key, val)
+}
 
-	// "github.com/gowebapi/webapi/html/media"
-	"audio": {"media", "Audio"},
-	"media": {"media", "Media"},
-	"track": {"media", "Track"},
-	"video": {"media", "Video"},
+func (webapiDialect) setTextContent(v, text, textVar string) string {
+	return fmt.Sprintf("%s := %s\n%s.SetTextContent(&%s)", textVar, strconv.Quote(text), v, textVar)
+}
+
+func (webapiDialect) appendText(v, text, textVar string) string {
+	return fmt.Sprintf("%s := _document.CreateTextNode(%s)\n%s.AppendChild(&%s.Node)", textVar, strconv.Quote(text), v, textVar)
+}
 
-	// "github.com/gowebapi/webapi/html"
-	"a":        {"html", "Anchor"},
-	"area":     {"html", "Area"},
-	"br":       {"html", "BR"},
-	"base":     {"html", "Base"},
-	"body":     {"html", "Body"},
-	"button":   {"html", "Button"},
-	"dl":       {"html", "DList"},
-	"data":     {"html", "Data"},
-	"datalist": {"html", "DataList"},
-	"details":  {"html", "Details"},
-	"dialog":   {"html", "Dialog"},
-	"dir":      {"html", "Directory"},
-	"div":      {"html", "Div"},
-	// skip type HTMLElement
-	"fieldset": {"html", "FieldSet"},
-	"font":     {"html", "Font"},
-	"form":     {"html", "Form"},
-	"frameset": {"html", "FrameSet"},
-	"hr":       {"html", "HR"},
-	"head":     {"html", "Head"},
-	"h1":       {"html", "Heading"},
-	"h2":       {"html", "Heading"},
-	"h3":       {"html", "Heading"},
-	"h4":       {"html", "Heading"},
-	"h5":       {"html", "Heading"},
-	"h6":       {"html", "Heading"},
-	"html":     {"html", "Html"},
-	"img":      {"html", "Image"},
-	"input":    {"html", "Input"},
-	"li":       {"html", "LI"},
-	"label":    {"html", "Label"},
-	"legend":   {"html", "Legend"},
-	"link":     {"html", "Link"},
-	"map":      {"html", "Map"},
-	"marquee":  {"html", "Marquee"},
-	"menu":     {"html", "Menu"},
-	"meta":     {"html", "Meta"},
-	"meter":    {"html", "Meter"},
-	"mod":      {"html", "Mod"},
-	"ol":       {"html", "OList"},
-	"optgroup": {"html", "OptGroup"},
-	"option":   {"html", "Option"},
-	"output":   {"html", "Output"},
-	"p":        {"html", "Paragraph"},
-	"param":    {"html", "Param"},
-	"picture":  {"html", "Picture"},
-	"pre":      {"html", "Pre"},
-	"progress": {"html", "Progress"},
-	"quote":    {"html", "Quote"},
-	"script":   {"html", "Script"},
-	"select":   {"html", "Select"},
-	"slot":     {"html", "Slot"},
-	"source":   {"html", "Source"},
-	"span":     {"html", "Span"},
-	"style":    {"html", "Style"},
-	"caption":  {"html", "TableCaption"},
-	"td":       {"html", "TableCell"},
-	"colgroup": {"html", "TableCol"},
-	"table":    {"html", "Table"},
-	"tr":       {"html", "TableRow"},
-	"tbody":    {"html", "TableSection"},
-	"template": {"html", "Template"},
-	"textarea": {"html", "TextArea"},
-	"time":     {"html", "Time"},
-	"title":    {"html", "Title"},
-	"ul":       {"html", "UList"},
-	// skip type HTMLUnknownElement
+func (webapiDialect) appendChild(parent, child string) string {
+	return fmt.Sprintf("%s.AppendChild(&%s.Node)", parent, child)
+}
+
+func (webapiDialect) firstElementChild(v string) string {
+	return v + ".FirstElementChild()"
+}
+
+func (webapiDialect) nextElementSibling(v string) string {
+	return v + ".NextElementSibling()"
+}
+
+func webapiNames(n *node) (typeName string, funcName string, ok bool) {
+	if n.kind != elementNode {
+		return "", "", false
+	}
+	t, _, ok := ir.ElementType(BackendWebAPI, n.tag)
+	if !ok {
+		return "", "", false
+	}
+	typeName = strings.TrimPrefix(t, "*")
+	return typeName, typeName + "FromJS", true
 }
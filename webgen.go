@@ -4,50 +4,21 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
-	"go/format"
-	"go/token"
 	"io"
+	"io/fs"
+	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"text/template"
 	"unicode"
 	"unicode/utf8"
 
-	"golang.org/x/net/html"
+	"github.com/littleroot/webgen/ir"
 )
 
-type tagAndVarName struct {
-	TagName string
-	VarName string
-}
-
-type stack struct {
-	s []tagAndVarName
-}
-
-func (st *stack) push(v tagAndVarName) {
-	st.s = append(st.s, v)
-}
-
-func (st *stack) pop() tagAndVarName {
-	v := st.s[len(st.s)-1]
-	st.s = st.s[:len(st.s)-1]
-	return v
-}
-
-func (st *stack) len() int {
-	return len(st.s)
-}
-
-func (st *stack) peek() (tagAndVarName, bool) {
-	if st.len() == 0 {
-		return tagAndVarName{}, false
-	}
-	return st.s[len(st.s)-1], true
-}
-
 type orderedSet struct {
 	m map[string]struct{}
 	s []string // insertion order; earliest inserted first
@@ -103,11 +74,182 @@ func (o *orderedSet) forEach(f func(string)) {
 type Options struct {
 	Package string // output package name
 	Root    string // root directory for absolute paths in <include /> elements
+
+	// EmbedCSS, if true, embeds each component's CSS in the views output
+	// instead of the CSS output. Constructors inject the component's CSS
+	// into the document the first time the component is constructed.
+	// The CSS output is empty. EmbedCSS is ignored by BackendSSR.
+	EmbedCSS bool
+
+	// MinifyCSS, if true, minifies the CSS output (or the embedded CSS, if
+	// EmbedCSS is set). Comments and insignificant whitespace are removed,
+	// colors and zero lengths are shortened, and duplicate or adjacent
+	// rules are merged where it is safe to do so.
+	MinifyCSS bool
+
+	// CSSSourceMapURL, if non-empty, causes a source map for the CSS output
+	// to be generated. The CSS output references the source map using this
+	// URL, which is typically relative to the CSS output's URL. The source
+	// map embeds the contents of the component files.
+	CSSSourceMapURL string
+
+	// CSSSourceMapDir is the directory of the source map file. If set,
+	// the paths of the source files in the source map are relative to it.
+	// Otherwise, they are the paths of the files as read, which are
+	// relative to the working directory unless absolute.
+	CSSSourceMapDir string
+
+	// CSSOrder optionally lists paths of component files in the order that
+	// their CSS should appear in the CSS output, in addition to the default
+	// order. By default, the CSS of a component appears after the CSS of
+	// the components it includes, and is otherwise ordered by path. A file
+	// must not be listed more than once.
+	CSSOrder []string
+
+	// Backend is the kind of Go code to generate for components.
+	Backend Backend
+
+	// Hydrate, if true, supports attaching components to DOM that was
+	// rendered elsewhere, such as by a server, from the same component
+	// files. BackendSSR marks each root element of a component Foo with
+	// the attribute data-w="Foo". The other backends additionally generate
+	// a function HydrateFoo(root) (*Foo, error), which validates the DOM
+	// starting at the component's first root element, and populates refs
+	// and roots without creating elements.
+	Hydrate bool
+
+	// CloneTemplates, if true, generates constructors that clone a
+	// <template> element containing the component's static markup,
+	// instead of creating each element and setting each attribute
+	// individually. This greatly reduces the number of calls into
+	// JavaScript. The template is parsed from the markup on first use,
+	// so the markup must parse to the same structure as written; for
+	// instance, a <table> must have explicit <tbody> elements.
+	// CloneTemplates is ignored by BackendSSR.
+	CloneTemplates bool
+
+	// CompactConstructors, if true, generates each constructor as a table
+	// of build operations, which is interpreted by a function shared by
+	// all components. The generated code is much smaller than with the
+	// default straight-line constructors, at the cost of some speed.
+	// Component types, refs, and Roots methods are unchanged.
+	// CompactConstructors is ignored by BackendSSR, and if CloneTemplates
+	// is set.
+	CompactConstructors bool
+
+	// ImportPath, if non-empty, is the import path of the generated
+	// package. It causes a manifest of the package's exported components
+	// to be produced, so that components in other packages can include
+	// them.
+	ImportPath string
+
+	// Manifests lists paths of manifest files of other packages, whose
+	// components can be included using the component attribute, such as
+	// <include component="github.com/acme/ui.Button"></include>.
+	Manifests []string
+
+	// PackagePerDir, if true, generates a Go package for each directory of
+	// component files, instead of a single package. The package for Root
+	// has the name Package and the import path ImportPath, which must be
+	// set. The package for a subdirectory of Root is named after the
+	// subdirectory, and its import path is ImportPath followed by the
+	// subdirectory's path. Includes of components in other directories
+	// refer to the other packages, so the included components must be
+	// exported. The views output is in Output.Packages.
+	PackagePerDir bool
+
+	// FilePerComponent, if true, generates the views for each component
+	// in a separate Go file, named after the component's type (for example,
+	// "Card_webgen.go" for "Card.html"), and the shared declarations in a
+	// file named "webgen.go". The files are in Output.Files, or in
+	// Package.Files with PackagePerDir. Components without views, which
+	// only have styles, have no file.
+	FilePerComponent bool
+
+	// Naming is the strategy for naming the Go types of components. The
+	// default, NamingBaseName, names a component after its file name.
+	// Components in the same package must have distinct type names.
+	Naming NamingStrategy
+
+	// ExportNames, if true, makes the type names of all components
+	// exported, even if their file names start with a lowercase letter.
+	// It does not apply to type names specified in component files with
+	// <meta name="webgen:name" content="...">.
+	ExportNames bool
+}
+
+// Backend is the kind of Go code generated for components.
+type Backend = ir.Backend
+
+const (
+	// BackendWebAPI generates constructors that build the DOM in the
+	// browser using the github.com/gowebapi/webapi packages.
+	BackendWebAPI = ir.BackendWebAPI
+
+	// BackendSSR generates functions that write the components' HTML to an
+	// io.Writer, for server-side rendering. The generated code does not
+	// depend on syscall/js. Each component Foo has a FooProps struct with
+	// a field for each ref, and a RenderFoo function.
+	BackendSSR = ir.BackendSSR
+
+	// BackendSyscallJS generates constructors that build the DOM in the
+	// browser using only the syscall/js package, which results in smaller
+	// binaries than BackendWebAPI. Elements, including refs, are of type
+	// js.Value.
+	BackendSyscallJS = ir.BackendSyscallJS
+
+	// BackendTinyGo is like BackendSyscallJS, but the generated code is
+	// tuned for TinyGo: it does not use fmt or other packages that depend
+	// on reflection.
+	BackendTinyGo = ir.BackendTinyGo
+)
+
+// Output is the output of generation.
+type Output struct {
+	Views        []byte // generated Go code
+	CSS          []byte // generated CSS; empty if CSS is embedded
+	CSSSourceMap []byte // source map for CSS; nil if Options.CSSSourceMapURL is empty
+	Manifest     []byte // JSON-encoded Manifest; nil if Options.ImportPath is empty or Options.PackagePerDir is set
+
+	// Packages is the views output for each package, sorted by directory,
+	// if Options.PackagePerDir is set. Views is then empty.
+	Packages []Package
+
+	// Deps lists the paths of the files that the output depends on, sorted:
+	// the component files, including those included by other components,
+	// the stylesheets they link, and manifests.
+	Deps []string
+
+	// Files is the views output as separate files, if
+	// Options.FilePerComponent is set and Options.PackagePerDir is not.
+	// Views is then empty.
+	Files []File
+}
+
+// Package is the views output for a Go package, for Options.PackagePerDir.
+type Package struct {
+	Dir        string // directory of the component files, relative to Options.Root
+	Name       string // package name
+	ImportPath string
+	Views      []byte // generated Go code; empty if Options.FilePerComponent is set
+	Files      []File // only if Options.FilePerComponent is set
 }
 
 // Generate generates the views and CSS code for the specified input file
 // paths. The error, if any, will be of type Error.
 func Generate(inputFiles []string, opts Options) (viewsOut, cssOut []byte, err error) {
+	out, err := GenerateOutput(inputFiles, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	return out.Views, out.CSS, nil
+}
+
+// GenerateOutput is like Generate, but returns all outputs, including those
+// that are only produced for certain options. If generation fails, the
+// Output is not nil: it has only Deps, which lists the files read before
+// the error, such as a file with a syntax error.
+func GenerateOutput(inputFiles []string, opts Options) (*Output, error) {
 	g := &generator{
 		opts:      opts,
 		generated: make(map[string]struct{}),
@@ -118,6 +260,24 @@ func Generate(inputFiles []string, opts Options) (viewsOut, cssOut []byte, err e
 	return g.run(inputFiles)
 }
 
+// GenerateFS is like GenerateOutput, but reads the input files, the files
+// they include or link, and manifests from fsys instead of the operating
+// system's file system. Paths, including Options.Root, are paths in fsys,
+// as described in fs.ValidPath; an empty Root is the root of fsys.
+func GenerateFS(fsys fs.FS, inputFiles []string, opts Options) (*Output, error) {
+	if opts.Root == "" {
+		opts.Root = "."
+	}
+	g := &generator{
+		opts:      opts,
+		generated: make(map[string]struct{}),
+		open: func(name string) (io.ReadCloser, error) {
+			return fsys.Open(filepath.ToSlash(name))
+		},
+	}
+	return g.run(inputFiles)
+}
+
 type Error struct {
 	Path string
 	Err  error
@@ -130,447 +290,464 @@ func (e Error) Error() string {
 type generator struct {
 	opts Options
 
-	generated        map[string]struct{}
-	open             func(string) (io.ReadCloser, error)
-	viewsBuf, cssBuf bytes.Buffer
+	generated map[string]struct{}
+	includes  map[string][]string // path -> paths directly included by it
+	open      func(string) (io.ReadCloser, error)
+	sources   map[string][]byte        // path -> contents; only if generating a source map
+	pkgs      map[string]*viewsPackage // dir -> package
+	typeNames map[string]string        // path -> type name of generated component
+	deps      map[string]struct{}      // paths of files read
+	cssChunks []cssChunk
+	manifests map[string]*Manifest // import path -> manifest
+}
+
+// cssChunk is the CSS from a top-level <style> or <link> element in a
+// component file.
+type cssChunk struct {
+	component string // path of the component file
+	path      string // path of the file containing the CSS
+	text      []byte // CSS, trimmed
+	line, col int    // zero-based position of text in the file
+	media     string // media attribute, if any
+}
+
+// wrap returns the text to surround the chunk's CSS with.
+func (c cssChunk) wrap() (prefix, suffix string) {
+	if c.media == "" {
+		return "", ""
+	}
+	return "@media " + c.media + " {\n", "\n}"
 }
 
 func (g *generator) reset() {
 	if len(g.generated) != 0 {
 		g.generated = make(map[string]struct{})
 	}
-	g.viewsBuf.Reset()
-	g.cssBuf.Reset()
+	g.includes = nil
+	g.sources = nil
+	g.pkgs = nil
+	g.typeNames = nil
+	g.deps = nil
+	g.cssChunks = nil
+	g.manifests = nil
 }
 
-func (g *generator) run(input []string) ([]byte, []byte, error) {
-	err := viewsHeaderTpl.Execute(&g.viewsBuf, g.opts.Package)
+func (g *generator) run(input []string) (*Output, error) {
+	out, err := g.generate(input)
 	if err != nil {
-		panic(err) // code bug: check template args?
+		return &Output{Deps: g.sortedDeps()}, err
 	}
+	return out, nil
+}
 
-	fmt.Fprint(&g.cssBuf, "/* Code generated by webgen. DO NOT EDIT. */\n\n")
+func (g *generator) sortedDeps() []string {
+	var deps []string
+	for p := range g.deps {
+		deps = append(deps, p)
+	}
+	sort.Strings(deps)
+	return deps
+}
+
+func (g *generator) generate(input []string) (*Output, error) {
+	if g.opts.PackagePerDir && g.opts.ImportPath == "" {
+		return nil, errors.New("PackagePerDir requires ImportPath")
+	}
+	if err := g.loadManifests(); err != nil {
+		return nil, err
+	}
+
+	if g.opts.CSSSourceMapURL != "" {
+		g.sources = make(map[string][]byte)
+	}
 
 	for _, p := range input {
 		err := g.generateOneFile(p, newOrderedSet(), "")
 		if err != nil {
-			return nil, nil, err
+			return nil, err
 		}
 	}
 
-	// Uncomment to debug.
-	// log.Println(string(g.viewsBuf.Bytes()))
+	out := Output{Deps: g.sortedDeps()}
 
-	// Run through gofmt-style formatting.
-	views, err := format.Source(g.viewsBuf.Bytes())
-	if err != nil {
-		panic(err) // code bug: we may have generated bad code
-	}
-
-	return views, g.cssBuf.Bytes(), nil
-}
-
-func (g *generator) generateOneFile(path string, history *orderedSet, fromPath string) error {
-	_, ok := g.generated[path]
-	if ok {
-		return nil // already generated
-	}
-
-	f, err := g.open(path)
-	if err != nil {
-		if fromPath != "" {
-			return fmt.Errorf("parsing %s: %w", fromPath, err)
+	if !g.embedCSS() {
+		if err := g.sortCSSChunks(); err != nil {
+			return nil, err
 		}
-		return err
+		out.CSS, out.CSSSourceMap = g.cssOutput()
 	}
-	defer f.Close()
 
-	views, css, err := g.generateComponent(f, path, history)
-	if err != nil {
-		return err
+	if g.opts.PackagePerDir {
+		if err := g.checkImportCycles(); err != nil {
+			return nil, err
+		}
+		for _, pkg := range g.sortedPackages() {
+			p := Package{
+				Dir:        pkg.dir,
+				Name:       pkg.name,
+				ImportPath: pkg.importPath,
+			}
+			if g.opts.FilePerComponent {
+				p.Files = g.files(pkg)
+			} else {
+				p.Views = g.views(pkg)
+			}
+			out.Packages = append(out.Packages, p)
+		}
+		return &out, nil
 	}
-	io.Copy(&g.viewsBuf, views)
-	io.Copy(&g.cssBuf, css)
-
-	g.generated[path] = struct{}{}
-	return nil
-}
 
-func isDisallowedRefName(name string) (disallowed bool, reason string) {
-	if token.IsKeyword(name) {
-		return true, "Go keyword"
+	pkg, _ := g.packageOf("") // the single package
+	if g.opts.FilePerComponent {
+		out.Files = g.files(pkg)
+	} else {
+		out.Views = g.views(pkg)
 	}
-	if !token.IsIdentifier(name) {
-		return true, "invalid Go identifier"
+	if g.opts.ImportPath != "" {
+		out.Manifest = g.manifest(pkg)
 	}
-	if name == "Roots" || name == "roots" {
-		return true, "internal use"
-	}
-	return false, ""
-}
-
-type tagAndVarAndTypeName struct {
-	TagName  string
-	VarName  string
-	TypeName string
-}
-
-func errDisallowedRefName(ref, reason string) error {
-	return fmt.Errorf("ref name %q disallowed (%s)", ref, reason)
-}
-
-func errRepeatedRefName(ref, prevTagName string) error {
-	return fmt.Errorf("ref name %q present multiple times (previous occurence in <%s>)", ref, prevTagName)
+	return &out, nil
 }
 
-func errUnclosedTags(remaining stack) error {
-	var tags []string
-	for _, t := range remaining.s {
-		tags = append(tags, t.TagName)
+// sortCSSChunks sorts the CSS chunks so that the CSS of a component comes
+// after the CSS of the components it includes (directly or indirectly), and
+// in the order specified by Options.CSSOrder. The sort is a topological sort
+// that is otherwise ordered by path, so that the result is independent of
+// the order of input files. Chunks from the same component retain their
+// order. Duplicate linked stylesheets are removed.
+func (g *generator) sortCSSChunks() error {
+	// before[p] lists the paths whose CSS must come before that of p.
+	before := make(map[string][]string)
+	for p, children := range g.includes {
+		before[p] = append(before[p], children...)
 	}
-	return fmt.Errorf("unclosed elements: %s", strings.Join(tags, ", "))
-}
 
-func (g *generator) generateComponent(
-	in io.Reader,
-	path string,
-	history *orderedSet,
-) (views, css io.Reader, err error) {
-	if history.has(path) {
-		var cycle []string
-		history.forEach(func(v string) {
-			cycle = append(cycle, filepath.Base(v))
-		})
-		cycle = append(cycle, filepath.Base(path))
-		return nil, nil, Error{
-			Path: path,
-			Err:  fmt.Errorf("cycle in include paths (%s)", strings.Join(cycle, " -> ")),
-		}
+	cleaned := make(map[string]string) // cleaned path -> path
+	for p := range g.generated {
+		cleaned[filepath.Clean(p)] = p
 	}
-
-	history.add(path)
-	defer history.remove(path)
-
-	typeName := componentTypeName(filepath.Base(path))
-	funcName := constructorFuncName(typeName)
-
-	var funcBuf bytes.Buffer
-
-	z := html.NewTokenizer(in)
-	namer := newVarNames()
-
-	var hasView bool                              // becomes true if a top-level, non-<style> start tag or self-closing tag is seen
-	var names stack                               // also used to record depth
-	var insideStyle bool                          // whether we break out inside top-level <style>
-	refs := make(map[string]tagAndVarAndTypeName) // ref attribute value -> names
-	var roots []string                            // roots var names
-
-tokenizeView:
-	for {
-		tt := z.Next()
-		switch tt {
-		case html.ErrorToken:
-			if z.Err() == io.EOF {
-				break tokenizeView
-			}
-			return nil, nil, Error{
-				Path: path,
-				Err:  fmt.Errorf("tokenize HTML: %w", z.Err()),
-			}
-
-		case html.TextToken:
-			if names.len() == 0 {
-				// text node without parent
-				// TODO: log a warning?
-				continue
-			}
-			text := formatTextContent(z.Text())
-			if len(text) == 0 {
-				continue
-			}
-			parent, _ := names.peek()
-			strName := namer.next("stringliteral")
-			fmt.Fprintf(&funcBuf, "%s := %s\n", strName, strconv.Quote(string(text)))
-			fmt.Fprintf(&funcBuf, "%s.SetTextContent(&%s)\n", parent.VarName, strName)
-
-		case html.StartTagToken:
-			tn, hasAttr := z.TagName()
-			tagName := string(tn)
-			varName := namer.next(tagName)
-
-			if tagName == "style" && names.len() == 0 {
-				insideStyle = true
-				break tokenizeView
-			}
-
-			if !hasView {
-				hasView = true
-				fmt.Fprintf(&funcBuf, "func %s() *%s {\n", funcName, typeName)
-			}
-
-			names.push(tagAndVarName{tagName, varName})
-
-			err := g.handleStartToken(&funcBuf, z, path, tagName, varName, hasAttr, refs, history)
-			if err != nil {
-				return nil, nil, err
-			}
-
-		case html.EndTagToken:
-			curr := names.pop()
-			err := g.handleEndToken(&funcBuf, path, curr.TagName, curr.VarName, &names,
-				func(root string) { roots = append(roots, root) })
-			if err != nil {
-				return nil, nil, err
-			}
-
-		case html.SelfClosingTagToken:
-			if !hasView {
-				hasView = true
-				fmt.Fprintf(&funcBuf, "func %s() *%s {\n", funcName, typeName)
-			}
-
-			tn, hasAttr := z.TagName()
-			tagName := string(tn)
-			varName := namer.next(tagName)
-
-			err := g.handleStartToken(&funcBuf, z, path, tagName, varName, hasAttr, refs, history)
-			if err != nil {
-				return nil, nil, err
+	var prev string
+	listed := make(map[string]bool)
+	for _, o := range g.opts.CSSOrder {
+		p, ok := cleaned[filepath.Clean(o)]
+		if !ok {
+			return Error{
+				Path: o,
+				Err:  errors.New("CSS order: not an input or included file"),
 			}
-
-			err = g.handleEndToken(&funcBuf, path, tagName, varName, &names,
-				func(root string) { roots = append(roots, root) })
-			if err != nil {
-				return nil, nil, err
+		}
+		if listed[p] {
+			return Error{
+				Path: o,
+				Err:  errors.New("CSS order: listed more than once"),
 			}
-
-		case html.CommentToken, html.DoctypeToken:
-			// ignore
 		}
+		listed[p] = true
+		if prev != "" {
+			before[p] = append(before[p], prev)
+		}
+		prev = p
 	}
 
-	if names.len() != 0 {
-		return nil, nil, Error{
-			Path: path,
-			Err:  errUnclosedTags(names),
+	// Kahn's algorithm, choosing the smallest path among the candidates at
+	// each step.
+	remaining := make(map[string]int) // path -> number of paths it must still come after
+	after := make(map[string][]string)
+	for p := range g.generated {
+		remaining[p] = len(before[p])
+		for _, b := range before[p] {
+			after[b] = append(after[b], p)
 		}
 	}
-
-	var typeBuf bytes.Buffer
-
-	if hasView {
-		writeReturn(&funcBuf, typeName, refs, roots)
-		fmt.Fprint(&funcBuf, "\n}\n\n")
-
-		writeRootsMethod(&funcBuf, typeName)
-		fmt.Fprint(&funcBuf, "\n\n")
-
-		writeTypeDefinition(&typeBuf, path, typeName, refs)
+	var ready []string
+	for p, n := range remaining {
+		if n == 0 {
+			ready = append(ready, p)
+		}
 	}
-
-	viewsBuf := io.MultiReader(&typeBuf, strings.NewReader("\n\n"), &funcBuf)
-
-	var cssBuf bytes.Buffer
-	if insideStyle {
-		if z.Next() != html.TextToken {
-			return nil, nil, Error{
-				Path: path,
-				Err:  errors.New("cannot find <style> text"),
+	sort.Strings(ready)
+
+	rank := make(map[string]int)
+	for len(ready) != 0 {
+		p := ready[0]
+		ready = ready[1:]
+		rank[p] = len(rank)
+		for _, a := range after[p] {
+			remaining[a]--
+			if remaining[a] == 0 {
+				i := sort.SearchStrings(ready, a)
+				ready = append(ready, "")
+				copy(ready[i+1:], ready[i:])
+				ready[i] = a
 			}
 		}
-		fmt.Fprintf(&cssBuf, "/* source: %s */\n\n%s\n\n", path, bytes.TrimSpace(z.Text()))
-		// NOTE: We dont't check for the end </style> tag.
 	}
 
-	return viewsBuf, &cssBuf, nil
-}
-
-func (g *generator) handleStartToken(w io.Writer, z *html.Tokenizer,
-	path, tagName, varName string, hasAttr bool,
-	refs map[string]tagAndVarAndTypeName, history *orderedSet) error {
-
-	if tagName == "include" {
-		return g.handleStartInclude(w, z, path, tagName, varName, hasAttr, refs, history)
+	if len(rank) != len(g.generated) {
+		var cycle []string
+		for p, n := range remaining {
+			if n != 0 {
+				cycle = append(cycle, p)
+			}
+		}
+		sort.Strings(cycle)
+		return Error{
+			Path: cycle[0],
+			Err:  fmt.Errorf("CSS order conflicts with include order (involving %s)", strings.Join(cycle, ", ")),
+		}
 	}
-	return g.handleStartRegular(w, z, path, tagName, varName, hasAttr, refs)
-}
 
-func (g *generator) handleStartRegular(w io.Writer, z *html.Tokenizer,
-	path, tagName, varName string, hasAttr bool,
-	refs map[string]tagAndVarAndTypeName) error {
+	sort.SliceStable(g.cssChunks, func(i, j int) bool {
+		return rank[g.cssChunks[i].component] < rank[g.cssChunks[j].component]
+	})
 
-	fmt.Fprintf(w, "%s := _document.CreateElement(%q, nil)\n", varName, tagName)
-	err := attrsFunc(z, hasAttr, func(k, v []byte) error {
-		if equalsRef(k) {
-			v := string(v)
-			if disallowed, reason := isDisallowedRefName(v); disallowed {
-				return Error{
-					Path: path,
-					Err:  errDisallowedRefName(v, reason),
-				}
-			}
-			ex, ok := refs[v]
-			if ok {
-				return Error{
-					Path: path,
-					Err:  errRepeatedRefName(v, ex.TagName),
-				}
+	// Keep only the first occurrence of a stylesheet linked multiple times
+	// with the same media.
+	type linkKey struct{ path, media string }
+	linked := make(map[linkKey]struct{})
+	chunks := g.cssChunks[:0]
+	for _, c := range g.cssChunks {
+		if c.path != c.component {
+			k := linkKey{c.path, c.media}
+			if _, ok := linked[k]; ok {
+				continue
 			}
-			refs[v] = tagAndVarAndTypeName{tagName, varName, ""}
-			return nil
+			linked[k] = struct{}{}
 		}
-		fmt.Fprintf(w, "%s.SetAttribute(%q, %q)\n", varName, k, v)
-		return nil
-	})
-
-	if err != nil {
-		return err
+		chunks = append(chunks, c)
 	}
-
+	g.cssChunks = chunks
 	return nil
 }
 
-func (g *generator) handleStartInclude(w io.Writer, z *html.Tokenizer,
-	path, tagName, varName string, hasAttr bool,
-	refs map[string]tagAndVarAndTypeName, history *orderedSet) error {
+const cssHeader = "/* Code generated by webgen. DO NOT EDIT. */\n"
 
-	var foundPathAttr bool
-	var refAttrVal string
-	var includeTypeName string
+func (g *generator) cssOutput() (css, sourceMap []byte) {
+	w := mappedWriter{}
+	if g.opts.CSSSourceMapURL != "" {
+		w.sm = newSourceMap()
+	}
 
-	err := attrsFunc(z, hasAttr, func(k, v []byte) error {
-		isRef := equalsRef(k)
-		isPath := equalsPath(k)
+	w.write(cssHeader)
+	if g.opts.MinifyCSS {
+		g.writeMinifiedCSS(&w)
+		w.write("\n")
+	} else {
+		w.write("\n")
+		g.writeCSS(&w)
+	}
 
-		// validate attributes
-		if !isRef && !isPath {
-			return Error{
-				Path: path,
-				Err:  fmt.Errorf("<include> specifies invalid attribute %q", k),
-			}
-		}
+	if w.sm == nil {
+		return w.Bytes(), nil
+	}
+	fmt.Fprintf(&w, "/*# sourceMappingURL=%s */\n", g.opts.CSSSourceMapURL)
+	return w.Bytes(), w.sm.encode()
+}
 
-		if isRef {
-			val := string(v)
-			if disallowed, reason := isDisallowedRefName(val); disallowed {
-				return Error{
-					Path: path,
-					Err:  errDisallowedRefName(val, reason),
-				}
+func (g *generator) writeCSS(w *mappedWriter) {
+	for i, c := range g.cssChunks {
+		if i == 0 || c.path != g.cssChunks[i-1].path {
+			w.write(fmt.Sprintf("/* source: %s */\n\n", c.path))
+		}
+		src := g.sourceIndex(w, c.path)
+		prefix, _ := c.wrap()
+		w.write(prefix)
+		for i, line := range strings.Split(string(c.text), "\n") {
+			col := 0
+			if i == 0 {
+				col = c.col
 			}
-			refAttrVal = val
-			return nil
+			if line != "" {
+				w.mark(src, c.line+i, col)
+			}
+			w.write(line + "\n")
 		}
-
-		// At this point, we have a "path" atrribute.
-		assert(isPath)
-
-		foundPathAttr = true
-		val := string(v)
-
-		var includePath string
-		if filepath.IsAbs(val) {
-			includePath = filepath.Join(g.opts.Root, val)
-		} else {
-			includePath = filepath.Join(filepath.Dir(path), val)
+		if c.media != "" {
+			w.write("}\n")
 		}
+		w.write("\n")
+	}
+}
+
+func (g *generator) writeMinifiedCSS(w *mappedWriter) {
+	// Minify all CSS together, so that rules can be merged across
+	// components. Record where each chunk starts, to map rules back to
+	// their source positions.
+	var all bytes.Buffer
+	starts := make([]int, len(g.cssChunks))     // offset of chunk, including any prefix
+	textStarts := make([]int, len(g.cssChunks)) // offset of chunk's text
+	for i, c := range g.cssChunks {
+		prefix, suffix := c.wrap()
+		starts[i] = all.Len()
+		all.WriteString(prefix)
+		textStarts[i] = all.Len()
+		all.Write(c.text)
+		all.WriteString(suffix)
+		all.WriteString("\n")
+	}
+
+	for _, r := range minifyCSSRules(all.Bytes()) {
+		i := sort.Search(len(starts), func(i int) bool { return starts[i] > r.pos }) - 1
+		c := g.cssChunks[i]
+		line, col := c.line, c.col
+		if r.pos > textStarts[i] {
+			line, col = textPosition(c.text, r.pos-textStarts[i], c.line, c.col)
+		}
+		w.mark(g.sourceIndex(w, c.path), line, col)
+		w.write(r.String())
+	}
+}
 
-		err := g.generateOneFile(includePath, history, path)
-		if err != nil {
-			return err
+// sourceIndex returns the index of the source file in the writer's source
+// map, adding it if necessary.
+func (g *generator) sourceIndex(w *mappedWriter, path string) int {
+	if w.sm == nil {
+		return 0
+	}
+	name := path
+	if g.opts.CSSSourceMapDir != "" {
+		if rel, err := filepath.Rel(g.opts.CSSSourceMapDir, path); err == nil {
+			name = rel
 		}
+	}
+	return w.sm.addSource(filepath.ToSlash(name), g.sources[path])
+}
 
-		// ... successfully included; construct it
-		includeTypeName = componentTypeName(filepath.Base(includePath))
-		includeConstructorFuncName := constructorFuncName(includeTypeName)
-		fmt.Fprintf(w, "%s := %s()\n", varName, includeConstructorFuncName)
-		return nil
-	})
+func (g *generator) generateOneFile(path string, history *orderedSet, fromPath string) error {
+	_, ok := g.generated[path]
+	if ok {
+		return nil // already generated
+	}
 
+	src, err := g.readFile(path)
 	if err != nil {
+		if fromPath != "" {
+			return fmt.Errorf("parsing %s: %w", fromPath, err)
+		}
 		return err
 	}
 
-	if !foundPathAttr {
+	pkg, err := g.packageOf(path)
+	if err != nil {
 		return Error{
 			Path: path,
-			Err:  errors.New(`missing required "path" attribute in <include>`),
+			Err:  err,
 		}
 	}
-	if refAttrVal != "" {
-		ex, ok := refs[refAttrVal]
-		if ok {
-			return Error{
-				Path: path,
-				Err:  errRepeatedRefName(refAttrVal, ex.TagName),
-			}
+
+	views, css, err := g.generateComponent(src, path, history)
+	if err != nil {
+		return err
+	}
+	if g.opts.FilePerComponent {
+		b, _ := ioutil.ReadAll(views)
+		if len(bytes.TrimSpace(b)) != 0 {
+			pkg.files = append(pkg.files, componentFile{componentFileName(g.typeName(path)), b})
 		}
-		refs[refAttrVal] = tagAndVarAndTypeName{tagName, varName, includeTypeName}
+	} else {
+		io.Copy(&pkg.buf, views)
 	}
+	g.cssChunks = append(g.cssChunks, css...)
+
+	g.generated[path] = struct{}{}
 	return nil
 }
 
-func (*generator) handleEndToken(w io.Writer, path, tagName, varName string,
-	names *stack, addRoot func(string)) error {
-	parent, ok := names.peek()
+func (g *generator) addDep(path string) {
+	if g.deps == nil {
+		g.deps = make(map[string]struct{})
+	}
+	g.deps[path] = struct{}{}
+}
 
-	if tagName == "include" {
-		if !ok {
-			// TODO: top-level <include> *can* be allowed. We just need to do
-			// a bit more code generation.
-			return Error{
-				Path: path,
-				Err:  errors.New("top-level <include> disallowed (hint: nest in <div> or <span>)"),
-			}
-		}
-		fmt.Fprintf(w, "for _, r := range %s.roots {\n", varName)
-		fmt.Fprintf(w, "%s.AppendChild(&r.Node)\n", parent.VarName)
-		fmt.Fprintf(w, "}\n")
-		return nil
+func (g *generator) readFile(path string) ([]byte, error) {
+	g.addDep(path)
+	f, err := g.open(path)
+	if err != nil {
+		return nil, err
 	}
+	defer f.Close()
 
-	if !ok {
-		// no parent; record as root
-		addRoot(varName)
-		return nil
+	b, err := ioutil.ReadAll(f)
+	if err != nil {
+		return nil, err
 	}
-	fmt.Fprintf(w, "%s.AppendChild(&%s.Node)\n", parent.VarName, varName)
-	return nil
+	if g.sources != nil {
+		g.sources[path] = b
+	}
+	return b, nil
 }
 
-func writeReturn(w io.Writer, typeName string, refs map[string]tagAndVarAndTypeName, roots []string) {
-	fmt.Fprintf(w, "return &%s{\n", typeName)
-	for k, r := range refs {
-		if _, f, ok := webapiNames(r.TagName); ok {
-			fmt.Fprintf(w, "%s: %s(%s),\n", k, f, r.VarName)
-		} else {
-			fmt.Fprintf(w, "%s: %s,\n", k, r.VarName)
+func (g *generator) generateComponent(
+	src []byte,
+	path string,
+	history *orderedSet,
+) (views io.Reader, css []cssChunk, err error) {
+	c, err := g.parseComponent(src, path, history)
+	if err != nil {
+		return nil, nil, err
+	}
+	pkg, _ := g.packageOf(path)
+	if c.hasView() && isExportedName(c.typeName) {
+		pkg.exported = append(pkg.exported, c.typeName)
+	}
+
+	var buf bytes.Buffer
+	css = c.css
+
+	if d := g.dialect(); d != nil {
+		writeDOMComponent(&buf, d, c, g.embeddedCSS(c), g.opts)
+		if g.embedCSS() {
+			css = nil
 		}
+	} else {
+		writeSSRComponent(&buf, c, g.opts.Hydrate)
 	}
-	fmt.Fprintf(w, "roots: []*dom.Element{%s},\n", strings.Join(roots, ", "))
-	fmt.Fprint(w, "}")
+
+	return &buf, css, nil
 }
 
-func writeRootsMethod(w io.Writer, typeName string) {
-	fmt.Fprintf(w, "func (v *%s) Roots() []*dom.Element {\n", typeName)
-	fmt.Fprintf(w, "return v.roots\n")
-	fmt.Fprintf(w, "}")
+// dialect returns the dialect for the backend, or nil if the backend does
+// not build the DOM in the browser.
+func (g *generator) dialect() dialect {
+	switch g.opts.Backend {
+	case BackendWebAPI:
+		return webapiDialect{}
+	case BackendSyscallJS:
+		return syscallJSDialect{}
+	case BackendTinyGo:
+		return tinyGoDialect{}
+	case BackendSSR:
+		return nil
+	}
+	panic("unknown backend")
 }
 
-func writeTypeDefinition(w io.Writer, path, typeName string, refs map[string]tagAndVarAndTypeName) {
-	fmt.Fprintf(w, "// source: %s\n\n", path)
-	fmt.Fprintf(w, "type %s struct {\n", typeName)
-	for k, v := range refs {
-		typeName := "*dom.Element"
-		if v.TypeName != "" {
-			typeName = "*" + v.TypeName
-		} else if t, _, ok := webapiNames(v.TagName); ok {
-			typeName = "*" + t
+// embedCSS reports whether CSS is embedded in the views output.
+func (g *generator) embedCSS() bool {
+	return g.opts.EmbedCSS && g.dialect() != nil
+}
+
+// embeddedCSS returns the CSS to embed for the component, or nil if CSS is
+// not embedded.
+func (g *generator) embeddedCSS(c *component) []byte {
+	if !g.embedCSS() || len(c.css) == 0 {
+		return nil
+	}
+	var buf bytes.Buffer
+	for i, chunk := range c.css {
+		if i != 0 {
+			buf.WriteString("\n\n")
 		}
-		fmt.Fprintf(w, "%s %s\n", k, typeName)
+		prefix, suffix := chunk.wrap()
+		buf.WriteString(prefix)
+		buf.Write(chunk.text)
+		buf.WriteString(suffix)
 	}
-	fmt.Fprint(w, "roots []*dom.Element\n")
-	fmt.Fprint(w, "}")
+	if g.opts.MinifyCSS {
+		return minifyCSS(buf.Bytes())
+	}
+	return buf.Bytes()
 }
 
 // varNames returns successive variable names to use in a component's
@@ -591,41 +768,24 @@ func (v *varNames) next(kind string) string {
 	return fmt.Sprintf("%s%d", kind, n)
 }
 
-func equalsRef(k []byte) bool {
-	return len(k) == 3 &&
-		k[0] == 'r' &&
-		k[1] == 'e' &&
-		k[2] == 'f'
-}
-
-func equalsPath(k []byte) bool {
-	return len(k) == 4 &&
-		k[0] == 'p' &&
-		k[1] == 'a' &&
-		k[2] == 't' &&
-		k[3] == 'h'
-}
-
 var (
 	newline = []byte{'\n'}
 )
 
-func componentTypeName(filename string) string {
-	// Remove what we assume to be the extension.
-	idx := strings.LastIndex(filename, ".")
-	if idx != -1 {
-		filename = filename[:idx]
+func toUppperFirstRune(n string) string {
+	r, i := utf8.DecodeRuneInString(n)
+	if i == 0 {
+		return n
 	}
-
-	return filename
+	return string([]rune{unicode.ToUpper(r)}) + n[i:]
 }
 
-func toUppperFirstRune(n string) string {
+func toLowerFirstRune(n string) string {
 	r, i := utf8.DecodeRuneInString(n)
 	if i == 0 {
 		return n
 	}
-	return string([]rune{unicode.ToUpper(r)}) + n[i:]
+	return string([]rune{unicode.ToLower(r)}) + n[i:]
 }
 
 func isExportedName(name string) bool {
@@ -640,28 +800,54 @@ func constructorFuncName(typeName string) string {
 	return "new" + toUppperFirstRune(typeName)
 }
 
-func attrsFunc(z *html.Tokenizer, hasAttr bool, f func(k, v []byte) error) error {
-	for hasAttr {
-		var k, v []byte
-		k, v, hasAttr = z.TagAttr()
-		if err := f(k, v); err != nil {
-			return err
-		}
+func injectCSSFuncName(typeName string) string {
+	if isExportedName(typeName) {
+		return "Inject" + typeName + "CSS"
 	}
-	return nil
+	return "inject" + toUppperFirstRune(typeName) + "CSS"
+}
+
+// goStringLiteral returns s as a Go string literal, preferring a raw string
+// literal for readability when possible.
+func goStringLiteral(s string) string {
+	if strings.ContainsAny(s, "`\r") {
+		return strconv.Quote(s)
+	}
+	return "`" + s + "`"
+}
+
+type viewsHeaderArgs struct {
+	Package        string
+	Imports        []importSpec // additional imports
+	EmbedCSS       bool
+	Hydrate        bool
+	CloneTemplates bool
+	Compact        bool
 }
 
-const viewsHeader = `package {{.}}
+// importSpecs is the part of a header template for viewsHeaderArgs.Imports,
+// which are in their own group at the end of the import declaration.
+const importSpecs = `{{if .Imports}}
+{{range .Imports}}
+	{{if .Name}}{{.Name}} {{end}}{{printf "%q" .Path}}
+{{- end}}
+{{end}}`
+
+const viewsHeader = `package {{.Package}}
 
 // Code generated by webgen. DO NOT EDIT.
 
 import (
+{{- if .Hydrate}}
+	"fmt"
+	"strings"
+{{end}}
 	"github.com/gowebapi/webapi"
 	"github.com/gowebapi/webapi/dom"
 	"github.com/gowebapi/webapi/html"
 	"github.com/gowebapi/webapi/html/canvas"
 	"github.com/gowebapi/webapi/html/media"
-)
+` + importSpecs + `)
 
 type (
 	_ *webapi.Document // prevent unused import errors
@@ -674,6 +860,126 @@ type (
 var (
 	_document = webapi.GetDocument()
 )
+{{if .EmbedCSS}}
+// _injectCSS appends a <style> element containing css to the document's
+// <head>, unless *injected is already true.
+func _injectCSS(injected *bool, css string) {
+	if *injected {
+		return
+	}
+	*injected = true
+	style := _document.CreateElement("style", nil)
+	style.SetTextContent(&css)
+	_document.Head().AppendChild(&style.Node)
+}
+{{end -}}
+{{if .CloneTemplates}}
+// _template is component markup, which is parsed into a <template> element
+// when first cloned.
+type _template struct {
+	html    string
+	content *dom.DocumentFragment
+}
+
+// clone returns the root elements of a deep copy of the template's content.
+func (t *_template) clone() []*dom.Element {
+	if t.content == nil {
+		e := _document.CreateElement("template", nil)
+		e.SetInnerHTML(t.html)
+		t.content = html.HTMLTemplateElementFromJS(e).Content()
+	}
+	deep := true
+	f := dom.DocumentFragmentFromJS(t.content.CloneNode(&deep))
+	var roots []*dom.Element
+	for e := f.FirstElementChild(); e != nil; e = e.NextElementSibling() {
+		roots = append(roots, e)
+	}
+	return roots
+}
+
+// _at returns the descendant of roots[root] at the path of child element
+// indices.
+func _at(roots []*dom.Element, root int, path ...int) *dom.Element {
+	e := roots[root]
+	for _, i := range path {
+		e = e.Children().Item(uint(i))
+	}
+	return e
+}
+
+// _replace replaces the placeholder element with the elements.
+func _replace(placeholder *dom.Element, elems []*dom.Element) {
+	parent := placeholder.ParentNode()
+	for _, e := range elems {
+		parent.InsertBefore(&e.Node, &placeholder.Node)
+	}
+	placeholder.Remove()
+}
+{{end -}}
+{{if .Compact}}
+// _build creates elements according to the build operations, and returns
+// them in the order created. The roots of included components are appended
+// in the order given. See the webgen source for the operations.
+func _build(ops []string, includes ...[]*dom.Element) []*dom.Element {
+	var elems, open []*dom.Element
+	for _, op := range ops {
+		switch op[0] {
+		case '<':
+			e := _document.CreateElement(op[1:], nil)
+			if len(open) != 0 {
+				open[len(open)-1].AppendChild(&e.Node)
+			}
+			elems = append(elems, e)
+			open = append(open, e)
+		case '>':
+			open = open[:len(open)-1]
+		case '=':
+			for i := 1; i < len(op); i++ {
+				if op[i] == '=' {
+					open[len(open)-1].SetAttribute(op[1:i], op[i+1:])
+					break
+				}
+			}
+		case '"':
+			t := _document.CreateTextNode(op[1:])
+			open[len(open)-1].AppendChild(&t.Node)
+		case '+':
+			for _, r := range includes[0] {
+				open[len(open)-1].AppendChild(&r.Node)
+			}
+			includes = includes[1:]
+		}
+	}
+	return elems
+}
+{{end -}}
+{{if .Hydrate}}
+// _hydrateCheck returns an error unless e is an element with the tag name
+// and, if marker is non-empty, the data-w marker.
+func _hydrateCheck(e *dom.Element, tagName, marker string) error {
+	if e == nil {
+		return fmt.Errorf("hydrate: missing <%s> element", tagName)
+	}
+	if !strings.EqualFold(e.TagName(), tagName) {
+		return fmt.Errorf("hydrate: expected <%s> element, found <%s>", tagName, strings.ToLower(e.TagName()))
+	}
+	if marker != "" {
+		if m := e.GetAttribute("data-w"); m == nil || *m != marker {
+			return fmt.Errorf("hydrate: expected <%s> element with data-w=%q", tagName, marker)
+		}
+	}
+	return nil
+}
+
+// _hydrateEnd returns an error if e, the element after the last expected
+// element, is not nil.
+func _hydrateEnd(e *dom.Element) error {
+	if e != nil {
+		return fmt.Errorf("hydrate: unexpected <%s> element", strings.ToLower(e.TagName()))
+	}
+	return nil
+}
+{{end -}}
 `
 
 var viewsHeaderTpl = template.Must(template.New("").Parse(viewsHeader))
@@ -2,12 +2,16 @@ package webgen
 
 import (
 	"bytes"
+	"encoding/json"
+	"errors"
 	"io"
+	"io/fs"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+	"testing/fstest"
 
 	"github.com/kylelemons/godebug/diff"
 )
@@ -18,6 +22,7 @@ func TestGenerateStandalone(t *testing.T) {
 		"Exported",
 		"multipleRoots",
 		"nested",
+		"mixedContent",
 		"ref",
 		"selfClosing",
 		"specificElement",
@@ -47,10 +52,10 @@ func TestGenerateStandalone(t *testing.T) {
 			expectc, err := ioutil.ReadFile(filepath.Join("testdata", "golden", "standalone", f+".golden.css"))
 			Ok(t, err)
 
-			gotv, gotc, err := g.run([]string{path})
+			out, err := g.run([]string{path})
 			Ok(t, err)
-			EqualBytes(t, expectv, gotv, bytes.TrimSpace)
-			EqualBytes(t, expectc, gotc, bytes.TrimSpace)
+			EqualBytes(t, expectv, out.Views, bytes.TrimSpace)
+			EqualBytes(t, expectc, out.CSS, bytes.TrimSpace)
 		})
 	}
 }
@@ -87,9 +92,760 @@ func TestGenerateInclude(t *testing.T) {
 			expectv, err := ioutil.ReadFile(filepath.Join("testdata", "golden", "include", f[0]+".golden.go"))
 			Ok(t, err)
 
-			gotv, _, err := g.run([]string{path})
+			out, err := g.run([]string{path})
 			Ok(t, err)
-			EqualBytes(t, expectv, gotv, bytes.TrimSpace)
+			EqualBytes(t, expectv, out.Views, bytes.TrimSpace)
+		})
+	}
+}
+
+func TestGenerateDeps(t *testing.T) {
+	g := generator{
+		opts: Options{
+			Package: "ui",
+		},
+		generated: make(map[string]struct{}),
+		open: func(name string) (io.ReadCloser, error) {
+			return os.Open(name)
+		},
+	}
+
+	out, err := g.run([]string{filepath.Join("testdata", "include", "multilevel.html")})
+	Ok(t, err)
+	expect := []string{
+		filepath.Join("testdata", "include", "includeMultipleRoots.html"),
+		filepath.Join("testdata", "include", "multilevel.html"),
+		filepath.Join("testdata", "standalone", "multipleRoots.html"),
+	}
+	Equal(t, strings.Join(expect, "\n"), strings.Join(out.Deps, "\n"))
+}
+
+func TestGenerateFS(t *testing.T) {
+	t.Run("dirFS", func(t *testing.T) {
+		expectv, err := ioutil.ReadFile(filepath.Join("testdata", "golden", "include", "absolutePath.golden.go"))
+		Ok(t, err)
+
+		out, err := GenerateFS(os.DirFS("."), []string{"testdata/include/absolutePath.html"}, Options{
+			Package: "ui",
+			Root:    "testdata",
+		})
+		Ok(t, err)
+		EqualBytes(t, expectv, out.Views, bytes.TrimSpace)
+	})
+
+	fsys := fstest.MapFS{
+		"Page.html":          {Data: []byte(`<div><include path="/widgets/Card.html"></include><include path="Footer.html"></include></div>`)},
+		"Footer.html":        {Data: []byte(`<footer></footer><link rel="stylesheet" href="widgets/footer.css">`)},
+		"widgets/Card.html":  {Data: []byte(`<div class="card"></div><style>.card { color: red; }</style>`)},
+		"widgets/footer.css": {Data: []byte(`footer { margin: 0; }`)},
+	}
+
+	t.Run("mapFS", func(t *testing.T) {
+		out, err := GenerateFS(fsys, []string{"Page.html"}, Options{Package: "ui"})
+		Ok(t, err)
+		Equal(t, "Footer.html\nPage.html\nwidgets/Card.html\nwidgets/footer.css", strings.Join(out.Deps, "\n"))
+		for _, s := range []string{"type Page struct", "type Footer struct", "type Card struct"} {
+			if !bytes.Contains(out.Views, []byte(s)) {
+				t.Errorf("views output missing %q", s)
+			}
+		}
+		for _, s := range []string{".card { color: red; }", "footer { margin: 0; }"} {
+			if !bytes.Contains(out.CSS, []byte(s)) {
+				t.Errorf("CSS output missing %q", s)
+			}
+		}
+	})
+
+	t.Run("notExist", func(t *testing.T) {
+		_, err := GenerateFS(fsys, []string{"Missing.html"}, Options{Package: "ui"})
+		if !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("expected fs.ErrNotExist, got %v", err)
+		}
+	})
+
+	t.Run("depsOnError", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"Page.html": {Data: []byte(`<div><include path="Bad.html"></include></div>`)},
+			"Bad.html":  {Data: []byte(`<div>`)},
+		}
+		out, err := GenerateFS(fsys, []string{"Page.html"}, Options{Package: "ui"})
+		if err == nil {
+			t.Fatal("expected error")
+		}
+		Equal(t, "Bad.html\nPage.html", strings.Join(out.Deps, "\n"))
+	})
+}
+
+func TestGenerateEmbedCSS(t *testing.T) {
+	files := []string{
+		"style",
+		"styleOnly",
+	}
+
+	g := generator{
+		opts: Options{
+			Package:  "ui",
+			EmbedCSS: true,
+		},
+		generated: make(map[string]struct{}),
+		open: func(name string) (io.ReadCloser, error) {
+			return os.Open(name)
+		},
+	}
+
+	for _, f := range files {
+		t.Run(f, func(t *testing.T) {
+			g.reset()
+			path := filepath.Join("testdata", "standalone", f+".html")
+
+			expectv, err := ioutil.ReadFile(filepath.Join("testdata", "golden", "embedCSS", f+".golden.go"))
+			Ok(t, err)
+
+			out, err := g.run([]string{path})
+			Ok(t, err)
+			EqualBytes(t, expectv, out.Views, bytes.TrimSpace)
+			EqualBytes(t, nil, out.CSS, bytes.TrimSpace)
+		})
+	}
+}
+
+func TestGenerateMinifyCSS(t *testing.T) {
+	g := generator{
+		opts: Options{
+			Package:   "ui",
+			MinifyCSS: true,
+		},
+		generated: make(map[string]struct{}),
+		open: func(name string) (io.ReadCloser, error) {
+			return os.Open(name)
+		},
+	}
+
+	expectc, err := ioutil.ReadFile(filepath.Join("testdata", "golden", "css", "minify.golden.css"))
+	Ok(t, err)
+
+	out, err := g.run([]string{
+		filepath.Join("testdata", "css", "minifyA.html"),
+		filepath.Join("testdata", "css", "minifyB.html"),
+	})
+	Ok(t, err)
+	EqualBytes(t, expectc, out.CSS, bytes.TrimSpace)
+}
+
+func TestGenerateCSSSourceMap(t *testing.T) {
+	testcases := []struct {
+		name   string
+		minify bool
+	}{
+		{"sourceMap", false},
+		{"sourceMapMinified", true},
+	}
+
+	g := generator{
+		opts: Options{
+			Package:         "ui",
+			CSSSourceMapURL: "components.css.map",
+		},
+		generated: make(map[string]struct{}),
+		open: func(name string) (io.ReadCloser, error) {
+			return os.Open(name)
+		},
+	}
+
+	for _, tt := range testcases {
+		t.Run(tt.name, func(t *testing.T) {
+			g.reset()
+			g.opts.MinifyCSS = tt.minify
+
+			expectc, err := ioutil.ReadFile(filepath.Join("testdata", "golden", "css", tt.name+".golden.css"))
+			Ok(t, err)
+			expectm, err := ioutil.ReadFile(filepath.Join("testdata", "golden", "css", tt.name+".golden.css.map"))
+			Ok(t, err)
+
+			out, err := g.run([]string{
+				filepath.Join("testdata", "css", "minifyA.html"),
+				filepath.Join("testdata", "css", "minifyB.html"),
+			})
+			Ok(t, err)
+			EqualBytes(t, expectc, out.CSS, bytes.TrimSpace)
+			EqualBytes(t, expectm, out.CSSSourceMap, bytes.TrimSpace)
+		})
+	}
+}
+
+func TestGenerateCSSOrder(t *testing.T) {
+	testcases := []struct {
+		name  string
+		order []string
+		err   string
+	}{
+		{"orderDefault", nil, ""},
+		{"orderExplicit", []string{"z", "a"}, ""},
+		{"orderConflict", []string{"parent", "child"}, "CSS order conflicts with include order (involving testdata/css/order/child.html, testdata/css/order/parent.html)"},
+		{"orderDuplicate", []string{"z", "a", "z"}, "testdata/css/order/z.html: CSS order: listed more than once"},
+		{"orderDuplicateAdjacent", []string{"a", "a"}, "testdata/css/order/a.html: CSS order: listed more than once"},
+	}
+
+	g := generator{
+		opts: Options{
+			Package: "ui",
+		},
+		generated: make(map[string]struct{}),
+		open: func(name string) (io.ReadCloser, error) {
+			return os.Open(name)
+		},
+	}
+
+	for _, tt := range testcases {
+		t.Run(tt.name, func(t *testing.T) {
+			g.reset()
+			g.opts.CSSOrder = nil
+			for _, o := range tt.order {
+				g.opts.CSSOrder = append(g.opts.CSSOrder, filepath.Join("testdata", "css", "order", o+".html"))
+			}
+
+			// Input order should not matter.
+			out, err := g.run([]string{
+				filepath.Join("testdata", "css", "order", "z.html"),
+				filepath.Join("testdata", "css", "order", "parent.html"),
+				filepath.Join("testdata", "css", "order", "a.html"),
+			})
+			if tt.err != "" {
+				if err == nil || !strings.HasSuffix(err.Error(), tt.err) {
+					t.Errorf("expected err to end with: %q, got: %v", tt.err, err)
+				}
+				return
+			}
+			Ok(t, err)
+
+			expectc, err := ioutil.ReadFile(filepath.Join("testdata", "golden", "css", tt.name+".golden.css"))
+			Ok(t, err)
+			EqualBytes(t, expectc, out.CSS, bytes.TrimSpace)
+		})
+	}
+}
+
+func TestGenerateCSSSourceMapDir(t *testing.T) {
+	out, err := GenerateOutput([]string{filepath.Join("testdata", "css", "minifyA.html")}, Options{
+		Package:         "ui",
+		CSSSourceMapURL: "components.css.map",
+		CSSSourceMapDir: filepath.Join("testdata", "golden"),
+	})
+	Ok(t, err)
+
+	var m struct {
+		Sources []string `json:"sources"`
+	}
+	Ok(t, json.Unmarshal(out.CSSSourceMap, &m))
+	Equal(t, "../css/minifyA.html", strings.Join(m.Sources, "\n"))
+}
+
+func TestGenerateStyles(t *testing.T) {
+	g := generator{
+		opts: Options{
+			Package: "ui",
+			Root:    "testdata",
+		},
+		generated: make(map[string]struct{}),
+		open: func(name string) (io.ReadCloser, error) {
+			return os.Open(name)
+		},
+	}
+
+	expectv, err := ioutil.ReadFile(filepath.Join("testdata", "golden", "css", "styles.golden.go"))
+	Ok(t, err)
+	expectc, err := ioutil.ReadFile(filepath.Join("testdata", "golden", "css", "styles.golden.css"))
+	Ok(t, err)
+
+	out, err := g.run([]string{
+		filepath.Join("testdata", "css", "styles.html"),
+		filepath.Join("testdata", "css", "linkShared.html"),
+	})
+	Ok(t, err)
+	EqualBytes(t, expectv, out.Views, bytes.TrimSpace)
+	EqualBytes(t, expectc, out.CSS, bytes.TrimSpace)
+}
+
+func TestGenerateSSR(t *testing.T) {
+	g := generator{
+		opts: Options{
+			Package:  "ui",
+			Backend:  BackendSSR,
+			EmbedCSS: true, // ignored
+		},
+		generated: make(map[string]struct{}),
+		open: func(name string) (io.ReadCloser, error) {
+			return os.Open(name)
+		},
+	}
+
+	expectv, err := ioutil.ReadFile(filepath.Join("testdata", "golden", "ssr", "page.golden.go"))
+	Ok(t, err)
+	expectc, err := ioutil.ReadFile(filepath.Join("testdata", "golden", "ssr", "page.golden.css"))
+	Ok(t, err)
+
+	out, err := g.run([]string{filepath.Join("testdata", "ssr", "page.html")})
+	Ok(t, err)
+	EqualBytes(t, expectv, out.Views, bytes.TrimSpace)
+	EqualBytes(t, expectc, out.CSS, bytes.TrimSpace)
+}
+
+func TestGenerateHydrate(t *testing.T) {
+	testcases := []struct {
+		name    string
+		backend Backend
+	}{
+		{"page", BackendWebAPI},
+		{"pageSSR", BackendSSR},
+	}
+
+	g := generator{
+		opts: Options{
+			Package: "ui",
+			Hydrate: true,
+		},
+		generated: make(map[string]struct{}),
+		open: func(name string) (io.ReadCloser, error) {
+			return os.Open(name)
+		},
+	}
+
+	for _, tt := range testcases {
+		t.Run(tt.name, func(t *testing.T) {
+			g.reset()
+			g.opts.Backend = tt.backend
+
+			expectv, err := ioutil.ReadFile(filepath.Join("testdata", "golden", "hydrate", tt.name+".golden.go"))
+			Ok(t, err)
+
+			out, err := g.run([]string{filepath.Join("testdata", "hydrate", "page.html")})
+			Ok(t, err)
+			EqualBytes(t, expectv, out.Views, bytes.TrimSpace)
+		})
+	}
+}
+
+func TestGenerateSyscallJS(t *testing.T) {
+	testcases := []struct {
+		dir     string
+		name    string
+		input   string
+		backend Backend
+		opts    Options
+	}{
+		{"syscalljs", "page", filepath.Join("testdata", "hydrate", "page.html"), BackendSyscallJS, Options{Hydrate: true}},
+		{"syscalljs", "style", filepath.Join("testdata", "standalone", "style.html"), BackendSyscallJS, Options{EmbedCSS: true}},
+		{"tinygo", "page", filepath.Join("testdata", "hydrate", "page.html"), BackendTinyGo, Options{Hydrate: true}},
+		{"syscalljs", "mixedContent", filepath.Join("testdata", "standalone", "mixedContent.html"), BackendSyscallJS, Options{}},
+	}
+
+	for _, tt := range testcases {
+		t.Run(tt.dir+"/"+tt.name, func(t *testing.T) {
+			g := generator{
+				opts:      tt.opts,
+				generated: make(map[string]struct{}),
+				open: func(name string) (io.ReadCloser, error) {
+					return os.Open(name)
+				},
+			}
+			g.opts.Package = "ui"
+			g.opts.Backend = tt.backend
+
+			expectv, err := ioutil.ReadFile(filepath.Join("testdata", "golden", tt.dir, tt.name+".golden.go"))
+			Ok(t, err)
+
+			out, err := g.run([]string{tt.input})
+			Ok(t, err)
+			EqualBytes(t, expectv, out.Views, bytes.TrimSpace)
+		})
+	}
+}
+
+func TestGenerateCloneTemplates(t *testing.T) {
+	testcases := []struct {
+		name    string
+		input   string
+		backend Backend
+		opts    Options
+	}{
+		{"page", filepath.Join("testdata", "hydrate", "page.html"), BackendWebAPI, Options{}},
+		{"pageSyscallJS", filepath.Join("testdata", "hydrate", "page.html"), BackendSyscallJS, Options{}},
+		{"style", filepath.Join("testdata", "standalone", "style.html"), BackendWebAPI, Options{EmbedCSS: true}},
+		{"mixedContent", filepath.Join("testdata", "standalone", "mixedContent.html"), BackendWebAPI, Options{}},
+	}
+
+	for _, tt := range testcases {
+		t.Run(tt.name, func(t *testing.T) {
+			g := generator{
+				opts:      tt.opts,
+				generated: make(map[string]struct{}),
+				open: func(name string) (io.ReadCloser, error) {
+					return os.Open(name)
+				},
+			}
+			g.opts.Package = "ui"
+			g.opts.Backend = tt.backend
+			g.opts.CloneTemplates = true
+
+			expectv, err := ioutil.ReadFile(filepath.Join("testdata", "golden", "cloneTemplates", tt.name+".golden.go"))
+			Ok(t, err)
+
+			out, err := g.run([]string{tt.input})
+			Ok(t, err)
+			EqualBytes(t, expectv, out.Views, bytes.TrimSpace)
+		})
+	}
+}
+
+func TestGenerateCompactConstructors(t *testing.T) {
+	testcases := []struct {
+		name    string
+		input   string
+		backend Backend
+	}{
+		{"page", filepath.Join("testdata", "hydrate", "page.html"), BackendWebAPI},
+		{"pageSyscallJS", filepath.Join("testdata", "hydrate", "page.html"), BackendSyscallJS},
+		{"mixedContent", filepath.Join("testdata", "standalone", "mixedContent.html"), BackendWebAPI},
+	}
+
+	g := generator{
+		opts: Options{
+			Package:             "ui",
+			CompactConstructors: true,
+		},
+		generated: make(map[string]struct{}),
+		open: func(name string) (io.ReadCloser, error) {
+			return os.Open(name)
+		},
+	}
+
+	for _, tt := range testcases {
+		t.Run(tt.name, func(t *testing.T) {
+			g.reset()
+			g.opts.Backend = tt.backend
+
+			expectv, err := ioutil.ReadFile(filepath.Join("testdata", "golden", "compact", tt.name+".golden.go"))
+			Ok(t, err)
+
+			out, err := g.run([]string{tt.input})
+			Ok(t, err)
+			EqualBytes(t, expectv, out.Views, bytes.TrimSpace)
+		})
+	}
+}
+
+func TestGenerateManifest(t *testing.T) {
+	newGenerator := func(opts Options) *generator {
+		return &generator{
+			opts:      opts,
+			generated: make(map[string]struct{}),
+			open: func(name string) (io.ReadCloser, error) {
+				return os.Open(name)
+			},
+		}
+	}
+
+	t.Run("design", func(t *testing.T) {
+		g := newGenerator(Options{
+			Package:    "design",
+			ImportPath: "example.com/design",
+		})
+
+		expectv, err := ioutil.ReadFile(filepath.Join("testdata", "golden", "manifest", "design.golden.go"))
+		Ok(t, err)
+		expectm, err := ioutil.ReadFile(filepath.Join("testdata", "golden", "manifest", "design.golden.json"))
+		Ok(t, err)
+
+		out, err := g.run([]string{filepath.Join("testdata", "manifest", "design", "Button.html")})
+		Ok(t, err)
+		EqualBytes(t, expectv, out.Views, bytes.TrimSpace)
+		EqualBytes(t, expectm, out.Manifest, bytes.TrimSpace)
+	})
+
+	testcases := []struct {
+		name     string
+		backend  Backend
+		manifest string
+	}{
+		{"app", BackendWebAPI, "design.json"},
+		{"appSSR", BackendSSR, "designSSR.json"},
+	}
+
+	for _, tt := range testcases {
+		t.Run(tt.name, func(t *testing.T) {
+			g := newGenerator(Options{
+				Package:   "app",
+				Backend:   tt.backend,
+				Manifests: []string{filepath.Join("testdata", "manifest", tt.manifest)},
+			})
+
+			expectv, err := ioutil.ReadFile(filepath.Join("testdata", "golden", "manifest", tt.name+".golden.go"))
+			Ok(t, err)
+
+			out, err := g.run([]string{filepath.Join("testdata", "manifest", "app.html")})
+			Ok(t, err)
+			EqualBytes(t, expectv, out.Views, bytes.TrimSpace)
+			EqualBytes(t, nil, out.Manifest, bytes.TrimSpace)
+		})
+	}
+
+	t.Run("collision", func(t *testing.T) {
+		g := newGenerator(Options{
+			Package: "app",
+			Manifests: []string{
+				filepath.Join("testdata", "manifest", "design.json"),
+				filepath.Join("testdata", "manifest", "dom.json"),
+				filepath.Join("testdata", "manifest", "otherDesign.json"),
+			},
+		})
+
+		expectv, err := ioutil.ReadFile(filepath.Join("testdata", "golden", "manifest", "collision.golden.go"))
+		Ok(t, err)
+
+		out, err := g.run([]string{filepath.Join("testdata", "manifest", "collision.html")})
+		Ok(t, err)
+		EqualBytes(t, expectv, out.Views, bytes.TrimSpace)
+	})
+
+	t.Run("duplicate", func(t *testing.T) {
+		g := newGenerator(Options{
+			Package: "app",
+			Manifests: []string{
+				filepath.Join("testdata", "manifest", "design.json"),
+				filepath.Join("testdata", "manifest", "designDuplicate.json"),
+			},
+		})
+
+		_, err := g.run([]string{filepath.Join("testdata", "manifest", "app.html")})
+		expect := `duplicate manifest for package "example.com/design" (also in testdata/manifest/design.json)`
+		if err == nil || !strings.HasSuffix(err.Error(), expect) {
+			t.Errorf("expected err to end with: %q, got: %v", expect, err)
+		}
+	})
+
+	errorcases := []struct {
+		filename string
+		opts     Options
+		err      string
+	}{
+		{"componentAndPath", Options{}, `<include> must not specify both "path" and "component" attributes`},
+		{"componentHydrate", Options{Hydrate: true}, `hydrating components from other packages (example.com/design.Button) is not supported`},
+		{"componentInvalid", Options{}, `component "Button" must be of the form <import-path>.<type>`},
+		{"componentUnknownPackage", Options{}, `no manifest for package "example.com/other" (hint: add its manifest to the manifests option)`},
+		{"componentUnknownType", Options{}, `component Input not in manifest for package "example.com/design"`},
+		{"componentBackend", Options{Backend: BackendSyscallJS}, `manifest is for backend "webapi", not "syscalljs"`},
+	}
+
+	for _, tt := range errorcases {
+		t.Run(tt.filename, func(t *testing.T) {
+			opts := tt.opts
+			opts.Package = "ui"
+			opts.Manifests = []string{filepath.Join("testdata", "manifest", "design.json")}
+			g := newGenerator(opts)
+
+			_, err := g.run([]string{filepath.Join("testdata", "error", tt.filename+".html")})
+			if err == nil {
+				t.Errorf("err unexpectedly nil")
+				return
+			}
+			if !strings.HasSuffix(err.Error(), tt.err) {
+				t.Errorf("expected err to end with: %q, got: %q", tt.err, err.Error())
+			}
+		})
+	}
+}
+
+func TestGenerateNaming(t *testing.T) {
+	newGenerator := func(opts Options) *generator {
+		return &generator{
+			opts:      opts,
+			generated: make(map[string]struct{}),
+			open: func(name string) (io.ReadCloser, error) {
+				return os.Open(name)
+			},
+		}
+	}
+	root := filepath.Join("testdata", "naming")
+
+	t.Run("dirPrefix", func(t *testing.T) {
+		g := newGenerator(Options{
+			Package: "ui",
+			Root:    root,
+			Naming:  NamingDirPrefix,
+		})
+
+		expectv, err := ioutil.ReadFile(filepath.Join("testdata", "golden", "naming", "dirPrefix.golden.go"))
+		Ok(t, err)
+
+		out, err := g.run([]string{filepath.Join(root, "Page.html")})
+		Ok(t, err)
+		EqualBytes(t, expectv, out.Views, bytes.TrimSpace)
+	})
+
+	sanitize := []struct {
+		name string
+		opts Options
+	}{
+		{"sanitize", Options{Package: "ui"}},
+		{"sanitizeExport", Options{Package: "ui", ExportNames: true}},
+	}
+
+	for _, tt := range sanitize {
+		t.Run(tt.name, func(t *testing.T) {
+			g := newGenerator(tt.opts)
+
+			expectv, err := ioutil.ReadFile(filepath.Join("testdata", "golden", "naming", tt.name+".golden.go"))
+			Ok(t, err)
+
+			out, err := g.run([]string{filepath.Join(root, "sanitize", "page.html")})
+			Ok(t, err)
+			EqualBytes(t, expectv, out.Views, bytes.TrimSpace)
+		})
+	}
+
+	t.Run("collision", func(t *testing.T) {
+		g := newGenerator(Options{
+			Package: "ui",
+			Root:    root,
+		})
+
+		_, err := g.run([]string{filepath.Join(root, "Page.html")})
+		expect := filepath.Join(root, "shop", "Button.html") + ": type name Button collides with component " + filepath.Join(root, "admin", "Button.html") + " (hint: rename one of the files, or use the dir-prefix naming strategy)"
+		if err == nil || err.Error() != expect {
+			t.Errorf("expected err: %q, got: %v", expect, err)
+		}
+	})
+}
+
+func TestGenerateFilePerComponent(t *testing.T) {
+	g := generator{
+		opts: Options{
+			Package:          "ui",
+			Hydrate:          true,
+			FilePerComponent: true,
+		},
+		generated: make(map[string]struct{}),
+		open: func(name string) (io.ReadCloser, error) {
+			return os.Open(name)
+		},
+	}
+
+	out, err := g.run([]string{filepath.Join("testdata", "hydrate", "page.html")})
+	Ok(t, err)
+	EqualBytes(t, nil, out.Views, bytes.TrimSpace)
+
+	expect := []string{"webgen.go", "card_webgen.go", "page_webgen.go"}
+	if len(out.Files) != len(expect) {
+		t.Fatalf("expected %d files, got %d", len(expect), len(out.Files))
+	}
+	for i, name := range expect {
+		f := out.Files[i]
+		Equal(t, name, f.Name)
+		expectv, err := ioutil.ReadFile(filepath.Join("testdata", "golden", "files", strings.TrimSuffix(name, ".go")+".golden.go"))
+		Ok(t, err)
+		EqualBytes(t, expectv, f.Views, bytes.TrimSpace)
+	}
+}
+
+func TestGeneratePackagePerDir(t *testing.T) {
+	newGenerator := func(opts Options) *generator {
+		return &generator{
+			opts:      opts,
+			generated: make(map[string]struct{}),
+			open: func(name string) (io.ReadCloser, error) {
+				return os.Open(name)
+			},
+		}
+	}
+
+	t.Run("packages", func(t *testing.T) {
+		root := filepath.Join("testdata", "packages")
+		g := newGenerator(Options{
+			Package:       "ui",
+			Root:          root,
+			ImportPath:    "example.com/ui",
+			PackagePerDir: true,
+		})
+
+		expectcss, err := ioutil.ReadFile(filepath.Join("testdata", "golden", "packages", "packages.golden.css"))
+		Ok(t, err)
+
+		out, err := g.run([]string{
+			filepath.Join(root, "Page.html"),
+			filepath.Join(root, "widgets", "icons", "Star.html"),
+		})
+		Ok(t, err)
+		EqualBytes(t, nil, out.Views, bytes.TrimSpace)
+		EqualBytes(t, nil, out.Manifest, bytes.TrimSpace)
+		EqualBytes(t, expectcss, out.CSS, bytes.TrimSpace)
+
+		expect := []struct {
+			dir, name, importPath, golden string
+		}{
+			{".", "ui", "example.com/ui", "ui.golden.go"},
+			{"widgets", "widgets", "example.com/ui/widgets", "widgets.golden.go"},
+			{filepath.Join("widgets", "icons"), "icons", "example.com/ui/widgets/icons", "icons.golden.go"},
+		}
+		if len(out.Packages) != len(expect) {
+			t.Fatalf("expected %d packages, got %d", len(expect), len(out.Packages))
+		}
+		for i, e := range expect {
+			p := out.Packages[i]
+			Equal(t, e.dir, p.Dir)
+			Equal(t, e.name, p.Name)
+			Equal(t, e.importPath, p.ImportPath)
+			expectv, err := ioutil.ReadFile(filepath.Join("testdata", "golden", "packages", e.golden))
+			Ok(t, err)
+			EqualBytes(t, expectv, p.Views, bytes.TrimSpace)
+		}
+	})
+
+	t.Run("collision", func(t *testing.T) {
+		// The packages of dom/ and the ui/ directories have names that are
+		// taken by a header import and by each other.
+		root := filepath.Join("testdata", "collision")
+		g := newGenerator(Options{
+			Package:       "ui",
+			Root:          root,
+			ImportPath:    "example.com/ui",
+			PackagePerDir: true,
+		})
+
+		expectv, err := ioutil.ReadFile(filepath.Join("testdata", "golden", "collision", "ui.golden.go"))
+		Ok(t, err)
+
+		out, err := g.run([]string{filepath.Join(root, "Page.html")})
+		Ok(t, err)
+		Equal(t, ".", out.Packages[0].Dir)
+		EqualBytes(t, expectv, out.Packages[0].Views, bytes.TrimSpace)
+	})
+
+	errorcases := []struct {
+		filename string
+		opts     Options
+		err      string
+	}{
+		{"unexported", Options{ImportPath: "example.com/ui"}, `included component widget is in another package, so it must be exported (hint: rename widget.html)`},
+		{"cycle", Options{ImportPath: "example.com/ui"}, `cycle in package imports (a -> b -> a)`},
+		{"cycle", Options{}, `PackagePerDir requires ImportPath`},
+	}
+
+	for _, tt := range errorcases {
+		t.Run(tt.filename, func(t *testing.T) {
+			opts := tt.opts
+			opts.Package = "ui"
+			opts.Root = filepath.Join("testdata", "error", "packages")
+			opts.PackagePerDir = true
+			g := newGenerator(opts)
+
+			_, err := g.run([]string{filepath.Join(opts.Root, tt.filename+".html")})
+			if err == nil {
+				t.Errorf("err unexpectedly nil")
+				return
+			}
+			if !strings.HasSuffix(err.Error(), tt.err) {
+				t.Errorf("expected err to end with: %q, got: %q", tt.err, err.Error())
+			}
 		})
 	}
 }
@@ -103,14 +859,28 @@ func TestGenerateError(t *testing.T) {
 		// right now, format.Source() panics.
 		// {"badHTML", ""},
 		{"cycle0Include", "cycle in include paths (cycle0Include.html -> cycle1Include.html -> cycle2Include.html -> cycle0Include.html)"},
+		// A file is parsed before the files it includes, so its syntax
+		// errors are reported before a cycle.
+		{"cycleSyntax0Include", "cycleSyntax0Include.html: 4:1: unexpected end tag </span>"},
 		{"disallowedRefNameKeyword", `ref name "select" disallowed (Go keyword)`},
 		{"disallowedRefNameIdentifier", `ref name "*" disallowed (invalid Go identifier)`},
 		{"disallowedRefNameRoots", `ref name "Roots" disallowed (internal use)`},
 		{"invalidAttrInclude", `<include> specifies invalid attribute "foo"`},
-		{"missingPathAttrInclude", `missing required "path" attribute in <include>`},
+		{"includeContent", `<include> must not contain elements`},
+		{"includeText", `<include> must not contain text`},
+		{"linkMissingHref", `missing required "href" attribute in <link>`},
+		{"linkRel", `top-level <link> must have rel="stylesheet"`},
+		{"metaInvalidName", `invalid type name "user-card" in <meta name="webgen:name">`},
+		{"metaName", `top-level <meta> must have name="webgen:name"`},
+		{"metaNameEmpty", `1:1: missing required "content" attribute in <meta name="webgen:name">`},
+		{"metaNameRepeated", `2:1: <meta name="webgen:name"> present multiple times`},
+		{"mismatchedEndTag", `3:1: unexpected end tag </div> (hint: close <span> first)`},
+		{"linkRemote", `<link> href "https://example.com/x.css" must be a local file`},
+		{"missingPathAttrInclude", `missing required "path" or "component" attribute in <include>`},
 		{"repeatedRef", `ref name "foo" present multiple times (previous occurence in <div>)`},
 		{"topLevelInclude", `top-level <include> disallowed (hint: nest in <div> or <span>)`},
 		{"unclosed", `unclosed elements: div, span`},
+		{"unexpectedEndTag", `unexpected end tag </span>`},
 	}
 
 	g := generator{
@@ -128,7 +898,7 @@ func TestGenerateError(t *testing.T) {
 			g.reset()
 			path := filepath.Join("testdata", "error", tt.filename+".html")
 
-			_, _, err := g.run([]string{path})
+			_, err := g.run([]string{path})
 			if err == nil {
 				t.Errorf("err unexpectedly nil")
 				return
@@ -140,6 +910,16 @@ func TestGenerateError(t *testing.T) {
 	}
 }
 
+func TestGenerateErrorPosition(t *testing.T) {
+	path := filepath.Join("testdata", "error", "repeatedRef.html")
+	_, err := GenerateOutput([]string{path}, Options{Package: "ui"})
+	var e Error
+	if !errors.As(err, &e) {
+		t.Fatalf("expected Error, got: %v", err)
+	}
+	Equal(t, path+`: 2:2: ref name "foo" present multiple times (previous occurence in <div>)`, err.Error())
+}
+
 func TestToUppperFirstRune(t *testing.T) {
 	testcases := []struct {
 		in, expect string
@@ -160,6 +940,50 @@ func TestToUppperFirstRune(t *testing.T) {
 	}
 }
 
+func TestComponentTypeName(t *testing.T) {
+	testcases := []struct {
+		in, expect string
+	}{
+		{"foo.html", "foo"},
+		{"FooBar.html", "FooBar"},
+		{"user-card.html", "userCard"},
+		{"User_card.html", "UserCard"},
+		{"my card.html", "myCard"},
+		{"foo.test.html", "fooTest"},
+		{"404-page.html", "404Page"},
+		{"-.html", ""},
+	}
+
+	for _, tt := range testcases {
+		t.Run(tt.in, func(t *testing.T) {
+			Equal(t, tt.expect, componentTypeName(tt.in))
+		})
+	}
+}
+
+func TestFixTypeName(t *testing.T) {
+	testcases := []struct {
+		in     string
+		export bool
+		expect string
+	}{
+		{"userCard", false, "userCard"},
+		{"userCard", true, "UserCard"},
+		{"404Page", false, "x404Page"},
+		{"404Page", true, "X404Page"},
+		{"select", false, "select_"},
+		{"select", true, "Select"},
+		{"string", false, "string_"},
+		{"", true, ""},
+	}
+
+	for _, tt := range testcases {
+		t.Run(tt.in, func(t *testing.T) {
+			Equal(t, tt.expect, fixTypeName(tt.in, tt.export))
+		})
+	}
+}
+
 func TestVarNamer(t *testing.T) {
 	namer := newVarNames()
 